@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// Contacts are stored in ~/.nomctl/addressbook.json and can be used as
+// @name wherever a command accepts an address.
+
+const addressBookFile = "addressbook.json"
+
+// contactSimilarityDistance is the largest number of edits between a
+// pasted address and a different saved contact that triggers a warning.
+const contactSimilarityDistance = 4
+
+var contactNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+func addressBookPath() string {
+	return filepath.Join(nomctlDir, addressBookFile)
+}
+
+func readAddressBook() (map[string]string, error) {
+	book := make(map[string]string)
+	data, err := os.ReadFile(addressBookPath())
+	if os.IsNotExist(err) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &book); err != nil {
+		return nil, fmt.Errorf("invalid address book %s: %w", addressBookPath(), err)
+	}
+	return book, nil
+}
+
+func writeAddressBook(book map[string]string) error {
+	data, err := json.MarshalIndent(book, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(addressBookPath(), data, 0600)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// resolveAddress parses s as an address or an @name contact. A pasted
+// address that closely resembles a different contact prints a warning,
+// since that is how poisoned or mistyped addresses usually look.
+func resolveAddress(s string) (types.Address, error) {
+	book, err := readAddressBook()
+	if err != nil {
+		return types.Address{}, err
+	}
+	if strings.HasPrefix(s, "@") {
+		a, ok := book[s[1:]]
+		if !ok {
+			return types.Address{}, fmt.Errorf("unknown contact %s", s)
+		}
+		return types.ParseAddress(a)
+	}
+
+	address, err := types.ParseAddress(s)
+	if err != nil {
+		return types.Address{}, err
+	}
+	for name, a := range book {
+		if a == address.String() {
+			continue
+		}
+		if d := editDistance(a, address.String()); d <= contactSimilarityDistance {
+			fmt.Printf("Warning! %s differs from contact @%s (%s) by only %d character(s)\n", address, name, a, d)
+		}
+	}
+	return address, nil
+}
+
+var znnCliAddressBookAdd = &cli.Command{
+	Name:  "addressbook.add",
+	Usage: "name address",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 2 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("addressbook.add name address")
+			return nil
+		}
+		name := strings.TrimPrefix(cCtx.Args().Get(0), "@")
+		if !contactNameRegexp.MatchString(name) {
+			fmt.Println("Error! Contact names may only contain letters, digits, _ and - and be up to 64 characters long")
+			return nil
+		}
+		address, err := types.ParseAddress(cCtx.Args().Get(1))
+		if err != nil {
+			fmt.Println("Error! Invalid address:", err)
+			return err
+		}
+		book, err := readAddressBook()
+		if err != nil {
+			return err
+		}
+		if existing, ok := book[name]; ok {
+			fmt.Println("Error! Contact @"+name, "already exists with address", existing)
+			return nil
+		}
+		for n, a := range book {
+			if a == address.String() {
+				fmt.Println("Warning!", address, "is already saved as @"+n)
+			}
+		}
+		book[name] = address.String()
+		if err := writeAddressBook(book); err != nil {
+			return err
+		}
+		fmt.Println("Added contact @"+name, address)
+		return nil
+	},
+}
+
+var znnCliAddressBookRemove = &cli.Command{
+	Name:  "addressbook.remove",
+	Usage: "name",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("addressbook.remove name")
+			return nil
+		}
+		name := strings.TrimPrefix(cCtx.Args().Get(0), "@")
+		book, err := readAddressBook()
+		if err != nil {
+			return err
+		}
+		if _, ok := book[name]; !ok {
+			return errors.New("unknown contact @" + name)
+		}
+		delete(book, name)
+		if err := writeAddressBook(book); err != nil {
+			return err
+		}
+		fmt.Println("Removed contact @" + name)
+		return nil
+	},
+}
+
+var znnCliAddressBookList = &cli.Command{
+	Name:  "addressbook.list",
+	Usage: "",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 0 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("addressbook.list")
+			return nil
+		}
+		book, err := readAddressBook()
+		if err != nil {
+			return err
+		}
+		if len(book) == 0 {
+			fmt.Println("No contacts found")
+			return nil
+		}
+		names := make([]string, 0, len(book))
+		for name := range book {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("@%s %s\n", name, book[name])
+		}
+		return nil
+	},
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"abc", "abd", 1},
+		{"abc", "ab", 1},
+		{"ab", "abc", 1},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"z1qqjnwjjpnue8xmmpanz6csze6tcmtzzdtfsww7", "z1qqjnwjjpnue8xmmpanz6csze6tcmtzzdtfsww8", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAddress(t *testing.T) {
+	defer func(dir string) { nomctlDir = dir }(nomctlDir)
+	nomctlDir = t.TempDir()
+	alice := testAddress(1)
+	if err := writeAddressBook(map[string]string{"alice": alice.String()}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{name: "contact", in: "@alice", want: alice.String()},
+		{name: "unknown contact", in: "@bob", wantErr: true},
+		{name: "address", in: testAddress(2).String(), want: testAddress(2).String()},
+		{name: "invalid address", in: "z1invalid", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAddress(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolved %s to %s", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// maxAmountExponent bounds the exponent parseAmount accepts, since scaling
+// an amount like "1e999999999" would allocate a number with that many
+// digits. No token supply comes close to 10^80 base units.
+const maxAmountExponent = 80
+
+// parseAmount converts a non-negative decimal string into base units of a
+// token with the given decimals. It accepts fractions ("1.5"), scientific
+// notation ("1.5e3") and underscores between digits ("1_000_000"), and
+// rejects amounts with more precision than the token has.
+func parseAmount(s string, decimals uint8) (*big.Int, error) {
+	if strings.Contains(s, "_") {
+		for i, part := range strings.Split(s, "_") {
+			if part == "" || (i > 0 && !isDigit(part[0])) || !isDigit(part[len(part)-1]) {
+				return nil, fmt.Errorf("invalid amount %q, underscores must separate digits", s)
+			}
+		}
+		s = strings.ReplaceAll(s, "_", "")
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	if d.Sign() < 0 {
+		return nil, fmt.Errorf("amount %q cannot be negative", s)
+	}
+	if exp := d.Exponent(); exp > maxAmountExponent || exp < -maxAmountExponent {
+		return nil, fmt.Errorf("amount %q is out of range", s)
+	}
+	scaled := d.Shift(int32(decimals))
+	if !scaled.Equal(scaled.Truncate(0)) {
+		return nil, fmt.Errorf("amount %q has more than %d decimals", s, decimals)
+	}
+	return scaled.BigInt(), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func parseTokenStandard(s string) (types.ZenonTokenStandard, error) {
+	switch strings.ToUpper(s) {
+	case "ZNN":
+		return types.ZnnTokenStandard, nil
+	case "QSR":
+		return types.QsrTokenStandard, nil
+	}
+	return types.ParseZTS(s)
+}
+
+// parseDecimals accepts ZNN, QSR or a number of decimals.
+func parseDecimals(s string) (uint8, error) {
+	switch strings.ToUpper(s) {
+	case "ZNN":
+		return ZnnDecimals, nil
+	case "QSR":
+		return QsrDecimals, nil
+	}
+	d, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid decimals %q, expected ZNN, QSR or a number", s)
+	}
+	return uint8(d), nil
+}
+
+var utilsConvert = &cli.Command{
+	Name:  "convert",
+	Usage: "amount [ZNN|QSR|decimals]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "from-base",
+			Usage: "Convert an amount in base units to a decimal amount",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if !(cCtx.NArg() == 1 || cCtx.NArg() == 2) {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("convert amount [ZNN|QSR|decimals]")
+			return nil
+		}
+		decimals := uint8(ZnnDecimals)
+		if cCtx.NArg() == 2 {
+			var err error
+			decimals, err = parseDecimals(cCtx.Args().Get(1))
+			if err != nil {
+				return err
+			}
+		}
+
+		if cCtx.Bool("from-base") {
+			amount, err := parseAmount(cCtx.Args().Get(0), 0)
+			if err != nil {
+				return err
+			}
+			fmt.Println(formatAmount(amount, decimals))
+			return nil
+		}
+		amount, err := parseAmount(cCtx.Args().Get(0), decimals)
+		if err != nil {
+			return err
+		}
+		fmt.Println(amount.String())
+		return nil
+	},
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals uint8
+		want     string
+		wantErr  bool
+	}{
+		{in: "1", decimals: 8, want: "100000000"},
+		{in: "1.5", decimals: 8, want: "150000000"},
+		{in: "0.00000001", decimals: 8, want: "1"},
+		{in: "1.5e3", decimals: 8, want: "150000000000"},
+		{in: "1_000_000", decimals: 0, want: "1000000"},
+		{in: "5e8", decimals: 0, want: "500000000"},
+		{in: "0", decimals: 8, want: "0"},
+		{in: "1e80", decimals: 0, want: "1" + strings.Repeat("0", 80)},
+		{in: "0.000000001", decimals: 8, wantErr: true},
+		{in: "1.5", decimals: 0, wantErr: true},
+		{in: "-1", decimals: 8, wantErr: true},
+		{in: "abc", decimals: 8, wantErr: true},
+		{in: "", decimals: 8, wantErr: true},
+		{in: "_1", decimals: 8, wantErr: true},
+		{in: "1_", decimals: 8, wantErr: true},
+		{in: "1__0", decimals: 8, wantErr: true},
+		{in: "1e81", decimals: 0, wantErr: true},
+		{in: "1e-81", decimals: 8, wantErr: true},
+		{in: "1e999999999", decimals: 8, wantErr: true},
+		{in: "1e-999999999", decimals: 8, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.in, tt.decimals)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAmount(%q, %d) = %s, want an error", tt.in, tt.decimals, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseAmount(%q, %d): %v", tt.in, tt.decimals, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("parseAmount(%q, %d) = %s, want %s", tt.in, tt.decimals, got, tt.want)
+		}
+	}
+}
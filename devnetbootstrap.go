@@ -0,0 +1,562 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	signer "github.com/ignition-pillar/go-zdk/wallet"
+	"github.com/ignition-pillar/go-zdk/zdk"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/nom"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/node"
+	"github.com/zenon-network/go-zenon/rpc/api/embedded"
+	"github.com/zenon-network/go-zenon/vm/constants"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+	"github.com/zenon-network/go-zenon/wallet"
+	"gopkg.in/yaml.v3"
+)
+
+// bootstrapFile describes the state devnet.bootstrap creates on a running
+// devnet with account blocks, for state the go-zenon genesis cannot hold.
+// Every step is skipped when it is already done, so the file can be
+// bootstrapped again.
+//
+// Accelerator-Z projects are accepted by go-zenon only after their voting
+// period, and a phase only after the previous one is paid, so phases are
+// added by bootstrapping the file again once their project and previous
+// phase allow it. Voters vote yes on every project and phase of the file
+// that is still being voted on.
+type bootstrapFile struct {
+	Sentinels []bootstrapAccount `yaml:"sentinels"`
+	Stakes    []bootstrapStake   `yaml:"stakes"`
+	Projects  []bootstrapProject `yaml:"projects"`
+	Voters    []bootstrapAccount `yaml:"voters"`
+}
+
+// bootstrapAccount is the signing key of a bootstrap entry: a keyStore of the
+// wallet directory and an index, or the producer of a generated devnet node
+// given by its config.json.
+type bootstrapAccount struct {
+	KeyStore   string `yaml:"keyStore"`
+	Index      uint32 `yaml:"index"`
+	NodeConfig string `yaml:"nodeConfig"`
+}
+
+// bootstrapStake is a stake entry of Amount ZNN for Months months.
+type bootstrapStake struct {
+	bootstrapAccount `yaml:",inline"`
+	Amount           string `yaml:"amount"`
+	Months           int64  `yaml:"months"`
+}
+
+// bootstrapProject is an Accelerator-Z project created by its owner account.
+// Amounts are in whole tokens.
+type bootstrapProject struct {
+	bootstrapAccount `yaml:",inline"`
+	Name             string           `yaml:"name"`
+	Description      string           `yaml:"description"`
+	Url              string           `yaml:"url"`
+	Znn              string           `yaml:"znn"`
+	Qsr              string           `yaml:"qsr"`
+	Phases           []bootstrapPhase `yaml:"phases"`
+}
+
+type bootstrapPhase struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Url         string `yaml:"url"`
+	Znn         string `yaml:"znn"`
+	Qsr         string `yaml:"qsr"`
+}
+
+func (a bootstrapAccount) check() error {
+	if (a.KeyStore == "") == (a.NodeConfig == "") {
+		return errors.New("exactly one of keyStore and nodeConfig must be set")
+	}
+	if a.NodeConfig != "" && a.Index != 0 {
+		return errors.New("index cannot be set with nodeConfig")
+	}
+	return nil
+}
+
+// parse returns the amount and the duration in seconds of the stake.
+func (s bootstrapStake) parse() (*big.Int, int64, error) {
+	amount, err := parseAmount(s.Amount, ZnnDecimals)
+	if err != nil {
+		return nil, 0, err
+	}
+	if amount.Cmp(constants.StakeMinAmount) < 0 {
+		return nil, 0, fmt.Errorf("amount must be at least %s ZNN", formatAmount(constants.StakeMinAmount, ZnnDecimals))
+	}
+	duration := s.Months * constants.StakeTimeUnitSec
+	if duration < constants.StakeTimeMinSec || duration > constants.StakeTimeMaxSec {
+		return nil, 0, fmt.Errorf("months must be between %d and %d", constants.StakeTimeMinSec/constants.StakeTimeUnitSec, constants.StakeTimeMaxSec/constants.StakeTimeUnitSec)
+	}
+	return amount, duration, nil
+}
+
+func parseBootstrapFunds(znn, qsr string) (*big.Int, *big.Int, error) {
+	znnAmount, err := parseGenesisAmount(znn, ZnnDecimals)
+	if err != nil {
+		return nil, nil, err
+	}
+	qsrAmount, err := parseGenesisAmount(qsr, QsrDecimals)
+	if err != nil {
+		return nil, nil, err
+	}
+	return znnAmount, qsrAmount, nil
+}
+
+func readBootstrapFile(path string) (*bootstrapFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b := &bootstrapFile{}
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(b); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid bootstrap file %s: %w", path, err)
+	}
+	for i, s := range b.Sentinels {
+		if err := s.check(); err != nil {
+			return nil, fmt.Errorf("sentinel %d: %w", i+1, err)
+		}
+	}
+	for i, s := range b.Stakes {
+		if err := s.check(); err != nil {
+			return nil, fmt.Errorf("stake %d: %w", i+1, err)
+		}
+		if _, _, err := s.parse(); err != nil {
+			return nil, fmt.Errorf("stake %d: %w", i+1, err)
+		}
+	}
+	names := make(map[string]bool)
+	for i, p := range b.Projects {
+		if err := p.check(); err != nil {
+			return nil, fmt.Errorf("project %d: %w", i+1, err)
+		}
+		if p.Name == "" {
+			return nil, fmt.Errorf("project %d: name must be set", i+1)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("project %q is set more than once", p.Name)
+		}
+		names[p.Name] = true
+		if _, _, err := parseBootstrapFunds(p.Znn, p.Qsr); err != nil {
+			return nil, fmt.Errorf("project %q: %w", p.Name, err)
+		}
+		phases := make(map[string]bool)
+		for j, ph := range p.Phases {
+			if ph.Name == "" {
+				return nil, fmt.Errorf("project %q phase %d: name must be set", p.Name, j+1)
+			}
+			if phases[ph.Name] {
+				return nil, fmt.Errorf("project %q phase %q is set more than once", p.Name, ph.Name)
+			}
+			phases[ph.Name] = true
+			if _, _, err := parseBootstrapFunds(ph.Znn, ph.Qsr); err != nil {
+				return nil, fmt.Errorf("project %q phase %q: %w", p.Name, ph.Name, err)
+			}
+		}
+	}
+	for i, v := range b.Voters {
+		if err := v.check(); err != nil {
+			return nil, fmt.Errorf("voter %d: %w", i+1, err)
+		}
+	}
+	return b, nil
+}
+
+// bootstrapKeys unlocks every keyStore once. KeyStores of the wallet
+// directory use --passphrase or ask for theirs, node producers use the
+// password of their config.json.
+type bootstrapKeys struct {
+	walletDir  string
+	passphrase string
+	keyStores  map[string]*wallet.KeyStore
+}
+
+func newBootstrapKeys(walletDir string, passphrase string) *bootstrapKeys {
+	return &bootstrapKeys{walletDir: walletDir, passphrase: passphrase, keyStores: make(map[string]*wallet.KeyStore)}
+}
+
+func (k *bootstrapKeys) signer(a bootstrapAccount) (signer.Signer, error) {
+	path, passphrase, index := filepath.Join(k.walletDir, a.KeyStore), k.passphrase, a.Index
+	if a.NodeConfig != "" {
+		data, err := os.ReadFile(a.NodeConfig)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &node.Config{}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("invalid node config %s: %w", a.NodeConfig, err)
+		}
+		if cfg.Producer == nil {
+			return nil, fmt.Errorf("node config %s has no producer", a.NodeConfig)
+		}
+		path, passphrase, index = cfg.Producer.KeyFilePath, cfg.Producer.Password, cfg.Producer.Index
+	}
+	ks, ok := k.keyStores[path]
+	if !ok {
+		if passphrase == "" {
+			var err error
+			if passphrase, err = readPassphrase("Insert passphrase for " + a.KeyStore + ":"); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if ks, err = readKeyStore(path, passphrase); err != nil {
+			return nil, fmt.Errorf("unlocking %s: %w", path, err)
+		}
+		k.keyStores[path] = ks
+	}
+	_, kp, err := ks.DeriveForIndexPath(index)
+	if err != nil {
+		return nil, err
+	}
+	return signer.NewSigner(kp), nil
+}
+
+// sendBootstrapBlock sends template and waits for its confirmation, as the
+// next bootstrap step can depend on it.
+func sendBootstrapBlock(cCtx *cli.Context, z *zdk.Zdk, template *nom.AccountBlock, kp signer.Signer) error {
+	block, err := sendBlock(cCtx, z, template, kp)
+	if err != nil || cCtx.Bool("wait") {
+		return err
+	}
+	_, err = waitForConfirmation(z, block.Hash, 1, cCtx.Duration("timeout"))
+	return err
+}
+
+// waitForBootstrapState polls done until an embedded contract has received
+// a sent block or --timeout elapses.
+func waitForBootstrapState(cCtx *cli.Context, what string, done func() (bool, error)) error {
+	deadline := time.Now().Add(cCtx.Duration("timeout"))
+	for {
+		ok, err := done()
+		if err != nil || ok {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %s", what)
+		}
+		time.Sleep(momentumPollInterval)
+	}
+}
+
+// bootstrapSentinel deposits the missing sentinel QSR of kp and registers its
+// sentinel. The account needs the ZNN and QSR --genesis-sentinel-funds gives.
+func bootstrapSentinel(cCtx *cli.Context, z *zdk.Zdk, kp signer.Signer) error {
+	address := kp.Address()
+	info, err := z.Embedded.Sentinel.GetByOwner(address)
+	if err != nil {
+		return err
+	}
+	if info != nil && info.Active {
+		fmt.Println("Sentinel", address, "is already registered")
+		return nil
+	}
+
+	deposited, err := z.Embedded.Sentinel.GetDepositedQsr(address)
+	if err != nil {
+		return err
+	}
+	if missing := new(big.Int).Sub(constants.SentinelQsrDepositAmount, deposited); missing.Sign() > 0 {
+		fmt.Println("Depositing", formatAmount(missing, QsrDecimals), "QSR for sentinel", address)
+		template, err := z.Embedded.Sentinel.DepositQsr(missing)
+		if err != nil {
+			return err
+		}
+		if err := sendBootstrapBlock(cCtx, z, template, kp); err != nil {
+			return err
+		}
+		// the sentinel contract has to receive the deposit before the
+		// registration
+		err = waitForBootstrapState(cCtx, "the QSR deposit of "+address.String(), func() (bool, error) {
+			deposited, err := z.Embedded.Sentinel.GetDepositedQsr(address)
+			return err == nil && deposited.Cmp(constants.SentinelQsrDepositAmount) >= 0, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	template, err := z.Embedded.Sentinel.Register()
+	if err != nil {
+		return err
+	}
+	if err := sendBootstrapBlock(cCtx, z, template, kp); err != nil {
+		return err
+	}
+	fmt.Println("Registered sentinel", address)
+	return nil
+}
+
+func stakeKey(amount *big.Int, duration int64) string {
+	return fmt.Sprintf("%s/%d", amount, duration)
+}
+
+// countStakeEntries counts the stake entries by amount and duration.
+func countStakeEntries(entries []*embedded.StakeEntry) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[stakeKey(e.Amount, e.ExpirationTimestamp-e.StartTimestamp)]++
+	}
+	return counts
+}
+
+// bootstrapStakes sends the stakes an account doesn't have yet. Every
+// existing entry with the same amount and duration stands for one stake of
+// the file.
+func bootstrapStakes(cCtx *cli.Context, z *zdk.Zdk, keys *bootstrapKeys, stakes []bootstrapStake) error {
+	existing := make(map[types.Address]map[string]int)
+	for i, s := range stakes {
+		kp, err := keys.signer(s.bootstrapAccount)
+		if err != nil {
+			return fmt.Errorf("stake %d: %w", i+1, err)
+		}
+		address := kp.Address()
+		counts, ok := existing[address]
+		if !ok {
+			stakeList, err := z.Embedded.Stake.GetEntriesByAddress(address, 0, rpcMaxPageSize)
+			if err != nil {
+				return err
+			}
+			counts = countStakeEntries(stakeList.Entries)
+			existing[address] = counts
+		}
+		// checked by readBootstrapFile
+		amount, duration, _ := s.parse()
+		if key := stakeKey(amount, duration); counts[key] > 0 {
+			counts[key]--
+			fmt.Println("Stake", i+1, "of", address, "already exists")
+			continue
+		}
+		template, err := z.Embedded.Stake.Stake(duration, amount)
+		if err != nil {
+			return err
+		}
+		if err := sendBootstrapBlock(cCtx, z, template, kp); err != nil {
+			return fmt.Errorf("stake %d: %w", i+1, err)
+		}
+		fmt.Println("Staked", formatAmount(amount, ZnnDecimals), "ZNN for", s.Months, "month(s) from", address)
+	}
+	return nil
+}
+
+// getBootstrapProjects returns the Accelerator-Z projects by name.
+func getBootstrapProjects(z *zdk.Zdk) (map[string]*embedded.Project, error) {
+	projectList, err := z.Embedded.Accelerator.GetAll(0, rpcMaxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	projects := make(map[string]*embedded.Project)
+	for _, p := range projectList.List {
+		projects[p.Name] = p
+	}
+	return projects, nil
+}
+
+// nextBootstrapPhase returns the first phase of the file the project doesn't
+// have, and whether go-zenon accepts it now: a project can only add a phase
+// once its last phase is paid.
+func nextBootstrapPhase(configured []bootstrapPhase, phases []*embedded.Phase) (*bootstrapPhase, bool) {
+	added := make(map[string]bool)
+	for _, ph := range phases {
+		added[ph.Phase.Name] = true
+	}
+	for i := range configured {
+		if !added[configured[i].Name] {
+			return &configured[i], len(phases) == 0 || phases[len(phases)-1].Phase.Status == definition.PaidStatus
+		}
+	}
+	return nil, false
+}
+
+// bootstrapProjects creates the projects of the file that don't exist and
+// adds the next phase of the active ones, waiting until the accelerator
+// contract has them.
+func bootstrapProjects(cCtx *cli.Context, z *zdk.Zdk, keys *bootstrapKeys, projects []bootstrapProject) error {
+	if len(projects) == 0 {
+		return nil
+	}
+	all, err := getBootstrapProjects(z)
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		kp, err := keys.signer(p.bootstrapAccount)
+		if err != nil {
+			return fmt.Errorf("project %q: %w", p.Name, err)
+		}
+		project := all[p.Name]
+		if project != nil && project.Owner != kp.Address() {
+			return fmt.Errorf("project %q already exists with owner %s", p.Name, project.Owner)
+		}
+
+		var template *nom.AccountBlock
+		var done func(*embedded.Project) bool
+		if project == nil {
+			znn, qsr, _ := parseBootstrapFunds(p.Znn, p.Qsr)
+			if template, err = z.Embedded.Accelerator.CreateProject(p.Name, p.Description, p.Url, znn, qsr); err != nil {
+				return err
+			}
+			fmt.Println("Creating project", p.Name)
+			done = func(project *embedded.Project) bool { return project != nil }
+		} else {
+			phase, ok := nextBootstrapPhase(p.Phases, project.Phases)
+			if phase == nil {
+				continue
+			}
+			if project.Status != definition.ActiveStatus {
+				fmt.Println("Project", p.Name, "is not active yet, bootstrap again to add phase", phase.Name, "once it is accepted")
+				continue
+			}
+			if !ok {
+				fmt.Println("The last phase of project", p.Name, "is not paid yet, bootstrap again to add phase", phase.Name, "once it is paid")
+				continue
+			}
+			znn, qsr, _ := parseBootstrapFunds(phase.Znn, phase.Qsr)
+			if template, err = z.Embedded.Accelerator.AddPhase(project.Id, phase.Name, phase.Description, phase.Url, znn, qsr); err != nil {
+				return err
+			}
+			fmt.Println("Adding phase", phase.Name, "to project", p.Name)
+			phases := len(project.Phases)
+			done = func(project *embedded.Project) bool { return len(project.Phases) > phases }
+		}
+
+		if err := sendBootstrapBlock(cCtx, z, template, kp); err != nil {
+			return fmt.Errorf("project %q: %w", p.Name, err)
+		}
+		err = waitForBootstrapState(cCtx, "project "+p.Name, func() (bool, error) {
+			if all, err = getBootstrapProjects(z); err != nil {
+				return false, err
+			}
+			return done(all[p.Name]), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bootstrapVotes votes yes with the pillar of every voter on the projects
+// and phases of the file that are being voted on.
+func bootstrapVotes(cCtx *cli.Context, z *zdk.Zdk, keys *bootstrapKeys, voters []bootstrapAccount, projects []bootstrapProject) error {
+	if len(voters) == 0 || len(projects) == 0 {
+		return nil
+	}
+	pillarInfoList, err := z.Embedded.Pillar.GetAll(0, rpcMaxPageSize)
+	if err != nil {
+		return err
+	}
+	pillarNames := make(map[types.Address]string)
+	for _, pillar := range pillarInfoList.List {
+		pillarNames[pillar.BlockProducingAddress] = pillar.Name
+		pillarNames[pillar.StakeAddress] = pillar.Name
+	}
+	all, err := getBootstrapProjects(z)
+	if err != nil {
+		return err
+	}
+
+	for i, v := range voters {
+		kp, err := keys.signer(v)
+		if err != nil {
+			return fmt.Errorf("voter %d: %w", i+1, err)
+		}
+		pillarName, ok := pillarNames[kp.Address()]
+		if !ok {
+			return fmt.Errorf("voter %d: %s is not the owner or producer of a pillar", i+1, kp.Address())
+		}
+		for _, p := range projects {
+			project := all[p.Name]
+			if project == nil {
+				continue
+			}
+			var ids []types.Hash
+			var names []string
+			if project.Status == definition.VotingStatus {
+				ids, names = append(ids, project.Id), append(names, "project "+p.Name)
+			}
+			for _, phase := range project.Phases {
+				if phase.Phase.Status == definition.VotingStatus {
+					ids, names = append(ids, phase.Phase.Id), append(names, "phase "+phase.Phase.Name+" of project "+p.Name)
+				}
+			}
+			for j, id := range ids {
+				template, err := z.Embedded.Accelerator.VoteByName(id, pillarName, definition.VoteYes)
+				if err != nil {
+					return err
+				}
+				if err := sendBootstrapBlock(cCtx, z, template, kp); err != nil {
+					return fmt.Errorf("voter %d: %w", i+1, err)
+				}
+				fmt.Println("Pillar", pillarName, "voted yes on", names[j])
+			}
+		}
+	}
+	return nil
+}
+
+var znnCliDevnetBootstrap = &cli.Command{
+	Name:  "devnet.bootstrap",
+	Usage: "Register sentinels, stake and create Accelerator-Z projects of a bootstrap file on a running devnet",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("devnet.bootstrap bootstrapFile")
+			return nil
+		}
+
+		b, err := readBootstrapFile(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error reading bootstrap file:", err)
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+		keys := newBootstrapKeys(walletDir, cCtx.String("passphrase"))
+
+		for i, s := range b.Sentinels {
+			kp, err := keys.signer(s)
+			if err != nil {
+				fmt.Println("Error getting signer of sentinel", i+1, ":", err)
+				return err
+			}
+			if err := bootstrapSentinel(cCtx, z, kp); err != nil {
+				fmt.Println("Error registering sentinel", kp.Address(), ":", err)
+				return err
+			}
+		}
+
+		if err := bootstrapStakes(cCtx, z, keys, b.Stakes); err != nil {
+			fmt.Println("Error bootstrapping stakes:", err)
+			return err
+		}
+		if err := bootstrapProjects(cCtx, z, keys, b.Projects); err != nil {
+			fmt.Println("Error bootstrapping projects:", err)
+			return err
+		}
+		if err := bootstrapVotes(cCtx, z, keys, b.Voters, b.Projects); err != nil {
+			fmt.Println("Error voting on projects:", err)
+			return err
+		}
+
+		fmt.Println("Done")
+		return nil
+	},
+}
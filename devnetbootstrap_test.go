@@ -0,0 +1,140 @@
+package main
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zenon-network/go-zenon/rpc/api/embedded"
+	"github.com/zenon-network/go-zenon/vm/constants"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+func TestReadBootstrapFile(t *testing.T) {
+	path := writeTestFile(t, "bootstrap.yaml", `
+sentinels:
+  - keyStore: z1qsentinel
+    index: 2
+  - nodeConfig: node1/config.json
+`)
+	b, err := readBootstrapFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bootstrapAccount{
+		{KeyStore: "z1qsentinel", Index: 2},
+		{NodeConfig: "node1/config.json"},
+	}
+	if !reflect.DeepEqual(b.Sentinels, want) {
+		t.Errorf("got sentinels %+v, want %+v", b.Sentinels, want)
+	}
+
+	for _, tt := range []struct {
+		name string
+		file string
+		err  string
+	}{
+		{"unknown field", "sentinel:\n  - keyStore: a\n", "field sentinel not found"},
+		{"no key", "sentinels:\n  - index: 1\n", "sentinel 1: exactly one of keyStore and nodeConfig"},
+		{"two keys", "sentinels:\n  - keyStore: a\n    nodeConfig: b\n", "sentinel 1: exactly one of keyStore and nodeConfig"},
+		{"node index", "sentinels:\n  - nodeConfig: b\n    index: 1\n", "sentinel 1: index cannot be set"},
+		{"stake amount", "stakes:\n  - keyStore: a\n    amount: 0.5\n    months: 1\n", "stake 1: amount must be at least 1 ZNN"},
+		{"stake months", "stakes:\n  - keyStore: a\n    amount: 1\n    months: 13\n", "stake 1: months must be between 1 and 12"},
+		{"project name", "projects:\n  - keyStore: a\n", "project 1: name must be set"},
+		{"project twice", "projects:\n  - keyStore: a\n    name: p\n  - keyStore: a\n    name: p\n", `project "p" is set more than once`},
+		{"phase amount", "projects:\n  - keyStore: a\n    name: p\n    phases:\n      - name: one\n        qsr: x\n", `project "p" phase "one": invalid amount`},
+		{"voter key", "voters:\n  - index: 1\n", "voter 1: exactly one of keyStore and nodeConfig"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readBootstrapFile(writeTestFile(t, "bootstrap.yaml", tt.file))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestReadBootstrapFileStakesAndProjects(t *testing.T) {
+	path := writeTestFile(t, "bootstrap.yaml", `
+stakes:
+  - keyStore: z1qstaker
+    amount: 1.5
+    months: 3
+projects:
+  - nodeConfig: node1/config.json
+    name: Devnet
+    znn: 10
+    phases:
+      - name: One
+        qsr: 100
+voters:
+  - nodeConfig: node1/config.json
+`)
+	b, err := readBootstrapFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount, duration, err := b.Stakes[0].parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount.Cmp(big.NewInt(150000000)) != 0 || duration != 3*constants.StakeTimeUnitSec {
+		t.Errorf("got stake %v for %d seconds", amount, duration)
+	}
+	if b.Stakes[0].KeyStore != "z1qstaker" || b.Projects[0].NodeConfig != "node1/config.json" {
+		t.Errorf("got accounts %+v and %+v", b.Stakes[0].bootstrapAccount, b.Projects[0].bootstrapAccount)
+	}
+	if len(b.Projects[0].Phases) != 1 || b.Projects[0].Phases[0].Qsr != "100" || len(b.Voters) != 1 {
+		t.Errorf("got projects %+v and voters %+v", b.Projects, b.Voters)
+	}
+}
+
+func TestCountStakeEntries(t *testing.T) {
+	month := int64(constants.StakeTimeUnitSec)
+	entries := []*embedded.StakeEntry{
+		{Amount: big.NewInt(100), StartTimestamp: 10, ExpirationTimestamp: 10 + month},
+		{Amount: big.NewInt(100), StartTimestamp: 20, ExpirationTimestamp: 20 + month},
+		{Amount: big.NewInt(100), StartTimestamp: 10, ExpirationTimestamp: 10 + 2*month},
+	}
+	counts := countStakeEntries(entries)
+	if got := counts[stakeKey(big.NewInt(100), month)]; got != 2 {
+		t.Errorf("got %d one month stakes, want 2", got)
+	}
+	if got := counts[stakeKey(big.NewInt(100), 2*month)]; got != 1 {
+		t.Errorf("got %d two month stakes, want 1", got)
+	}
+	if got := counts[stakeKey(big.NewInt(200), month)]; got != 0 {
+		t.Errorf("got %d stakes of another amount, want 0", got)
+	}
+}
+
+func TestNextBootstrapPhase(t *testing.T) {
+	configured := []bootstrapPhase{{Name: "one"}, {Name: "two"}}
+	phase := func(name string, status uint8) *embedded.Phase {
+		return &embedded.Phase{Phase: &definition.Phase{Name: name, Status: status}}
+	}
+	tests := []struct {
+		name   string
+		phases []*embedded.Phase
+		want   string
+		ok     bool
+	}{
+		{"no phases", nil, "one", true},
+		{"unpaid phase", []*embedded.Phase{phase("one", definition.ActiveStatus)}, "two", false},
+		{"paid phase", []*embedded.Phase{phase("one", definition.PaidStatus)}, "two", true},
+		{"all phases", []*embedded.Phase{phase("one", definition.PaidStatus), phase("two", definition.VotingStatus)}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok := nextBootstrapPhase(configured, tt.phases)
+			got := ""
+			if next != nil {
+				got = next.Name
+			}
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("got %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
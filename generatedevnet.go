@@ -1,17 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/log"
 	"github.com/tyler-smith/go-bip39"
 	"github.com/urfave/cli/v2"
 	"github.com/zenon-network/go-zenon/chain/genesis"
@@ -40,28 +47,120 @@ var (
 		Value: "DataPath/genesis.json",
 	}
 
+	GenesisTemplateFlag = cli.StringFlag{
+		Name:  "genesis-template",
+		Usage: "YAML file describing the genesis, the genesis flags are applied on top of it",
+	}
+
+	ChainIdFlag = cli.Uint64Flag{
+		Name:  "chain-id",
+		Usage: "Chain identifier of the devnet (default: 321)",
+	}
+
+	ExtraDataFlag = cli.StringFlag{
+		Name:  "extra-data",
+		Usage: "Extra data of the genesis momentum",
+	}
+
+	GenesisTimestampFlag = cli.StringFlag{
+		Name:  "genesis-timestamp",
+		Usage: "Fixed genesis time as unix seconds or RFC 3339, for reproducible genesis hashes (default: now)",
+	}
+
 	GenesisBlockFlag = cli.StringSliceFlag{
 		Name:  "genesis-block",
-		Usage: "<address>/<ZnnAmount>/<QsrAmount>",
+		Usage: "<address>/<ZnnAmount>/<QsrAmount>, amounts may be fractional",
 	}
 
 	GenesisFusionFlag = cli.StringSliceFlag{
 		Name:  "genesis-fusion",
-		Usage: "<address>/<QsrAmount>",
+		Usage: "<address>/<QsrAmount>, amount may be fractional",
+	}
+
+	GenesisPillarFlag = cli.StringSliceFlag{
+		Name:  "genesis-pillar",
+		Usage: "<name>/<producerAddress>/<stakeAddress>/<rewardAddress>/<ZnnAmount>, stake and reward default to the producer and the amount to 15000",
+	}
+
+	GenesisDelegationFlag = cli.StringSliceFlag{
+		Name:  "genesis-delegation",
+		Usage: "<address>/<pillarName>",
+	}
+
+	GenesisLegacyPillarFlag = cli.StringSliceFlag{
+		Name:  "genesis-legacy-pillar",
+		Usage: "<keyIdHash>/<pillarCount> legacy pillar entry, see utils swap-keyid",
+	}
+
+	GenesisSentinelFundsFlag = cli.StringSliceFlag{
+		Name:  "genesis-sentinel-funds",
+		Usage: "<address>, funded with the ZNN and QSR a sentinel registration locks. Genesis cannot register sentinels, register it on the running devnet with znn-cli devnet.bootstrap",
+	}
+
+	GenesisSwapFlag = cli.StringSliceFlag{
+		Name:  "genesis-swap",
+		Usage: "<keyIdHash>/<ZnnAmount>/<QsrAmount> legacy swap entry, see utils swap-keyid",
 	}
 
-	// TODO
 	SporkAddressFlag = cli.StringFlag{
 		Name:  "spork-address",
-		Usage: "<address>",
+		Usage: "<address>, defaults to the producer address",
 	}
 
-	// TODO
-	GenesisSporkFlag = cli.StringSliceFlag{
+	// GenesisSporkFlag entries are comma separated, so it is not a
+	// StringSliceFlag, which would split them on the commas
+	GenesisSporkFlag = cli.GenericFlag{
 		Name:  "genesis-spork",
-		Usage: "<hashId>,<activationStatus: true,false>",
+		Usage: "<hashId>,<activationStatus: true,false>[,<enforcementHeight>[,<name>[,<description>]]], ids not implemented by go-zenon require a name, can be repeated",
+		Value: &rawStringSlice{},
 	}
 
+	NodesFlag = cli.IntFlag{
+		Name:  "nodes",
+		Usage: "Number of nodes to generate, each in its own DataPath/node-<n> folder and with its own pillar",
+		Value: 1,
+	}
+
+	BasePortFlag = cli.IntFlag{
+		Name:  "base-port",
+		Usage: "p2p port of the first node, its RPC ports follow and every next node uses ports 10 higher",
+	}
+
+	HostFlag = cli.StringFlag{
+		Name:  "host",
+		Usage: "IP address the nodes are reachable on, used for the seeders",
+		Value: "127.0.0.1",
+	}
+
+	ProducerPasswordFlag = cli.StringFlag{
+		Name:  "producer-password",
+		Usage: "Password of the producer keystores, defaults to a random password",
+	}
+
+	ProducerPasswordFileFlag = cli.StringFlag{
+		Name:  "producer-password-file",
+		Usage: "File containing the password of the producer keystores",
+	}
+
+	ProducerMnemonicFileFlag = cli.StringFlag{
+		Name:  "producer-mnemonic-file",
+		Usage: "File containing the producer mnemonics to import, one per line and node",
+	}
+
+	ProducerMnemonicOutputFlag = cli.StringFlag{
+		Name:  "producer-mnemonic-output",
+		Usage: "Write the generated producer mnemonics to this file instead of printing them",
+	}
+
+	ZnnNameFlag      = tokenFlag("znn", "name")
+	ZnnSymbolFlag    = tokenFlag("znn", "symbol")
+	ZnnDomainFlag    = tokenFlag("znn", "domain")
+	ZnnMaxSupplyFlag = tokenFlag("znn", "max-supply")
+	QsrNameFlag      = tokenFlag("qsr", "name")
+	QsrSymbolFlag    = tokenFlag("qsr", "symbol")
+	QsrDomainFlag    = tokenFlag("qsr", "domain")
+	QsrMaxSupplyFlag = tokenFlag("qsr", "max-supply")
+
 	devnetCommand = cli.Command{
 		Action:    devnetAction,
 		Name:      "generate-devnet",
@@ -73,14 +172,78 @@ var (
 			&DataPathFlag,
 			&WalletDirFlag,
 			&GenesisFileFlag,
+			&GenesisTemplateFlag,
+			&ChainIdFlag,
+			&ExtraDataFlag,
+			&GenesisTimestampFlag,
+			&ZnnNameFlag,
+			&ZnnSymbolFlag,
+			&ZnnDomainFlag,
+			&ZnnMaxSupplyFlag,
+			&QsrNameFlag,
+			&QsrSymbolFlag,
+			&QsrDomainFlag,
+			&QsrMaxSupplyFlag,
 			&GenesisBlockFlag,
 			&GenesisFusionFlag,
+			&GenesisPillarFlag,
+			&GenesisDelegationFlag,
+			&GenesisLegacyPillarFlag,
+			&GenesisSentinelFundsFlag,
+			&GenesisSwapFlag,
 			&SporkAddressFlag,
 			&GenesisSporkFlag,
+			&NodesFlag,
+			&BasePortFlag,
+			&HostFlag,
+			&ProducerPasswordFlag,
+			&ProducerPasswordFileFlag,
+			&ProducerMnemonicFileFlag,
+			&ProducerMnemonicOutputFlag,
 		},
 	}
 )
 
+func tokenFlag(token string, field string) cli.StringFlag {
+	usage := fmt.Sprintf("Token %s of %s", strings.ReplaceAll(field, "-", " "), strings.ToUpper(token))
+	if field == "max-supply" {
+		usage += ", may be fractional"
+	}
+	return cli.StringFlag{
+		Name:  token + "-" + field,
+		Usage: usage,
+	}
+}
+
+// Ports of a node relative to its p2p port, matching the go-zenon defaults,
+// and the distance between the ports of consecutive nodes.
+const (
+	devnetHTTPPortOffset = 2
+	devnetWSPortOffset   = 3
+	devnetPortStride     = 10
+)
+
+// pillar name rules of the pillar contract
+var pillarNameRegexp = regexp.MustCompile("^([a-zA-Z0-9]+[-._]?)*[a-zA-Z0-9]$")
+
+const pillarNameMaxLength = 40
+
+// token metadata limits of the token contract
+const (
+	tokenNameMaxLength   = 40
+	tokenSymbolMaxLength = 10
+	tokenDomainMaxLength = 128
+)
+
+// tokenMaxSupplyLimit is 2^255-1, the largest max supply the token contract
+// accepts.
+var tokenMaxSupplyLimit = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(1))
+
+var (
+	genesisFusionMinAmount = big.NewInt(1 * constants.Decimals)
+	genesisFusionMaxAmount = big.NewInt(5000 * constants.Decimals)
+)
+
 func devnetAction(ctx *cli.Context) error {
 
 	cfg := node.DefaultNodeConfig
@@ -103,6 +266,22 @@ func devnetAction(ctx *cli.Context) error {
 	if err := validateDevnetFlags(ctx); err != nil {
 		return err
 	}
+	template, err := readGenesisTemplate(ctx.String(GenesisTemplateFlag.Name))
+	if err != nil {
+		return err
+	}
+	if err := mergeGenesisFlags(ctx, template); err != nil {
+		return err
+	}
+	// catch genesis errors before any keys are generated, using placeholder
+	// producers that are replaced by the generated ones
+	placeholders := make([]types.Address, ctx.Int(NodesFlag.Name))
+	for i := range placeholders {
+		placeholders[i] = types.PubKeyToAddress(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+	if _, err := createDevGenesis(template, placeholders); err != nil {
+		return err
+	}
 
 	// 2: Make dir paths absolute
 	if err := cfg.MakePathsAbsolute(); err != nil {
@@ -110,338 +289,807 @@ func devnetAction(ctx *cli.Context) error {
 	}
 
 	// 3: Check/Create dirs
-	if err := checkCreatePaths(&cfg); err != nil {
+	nodes := ctx.Int(NodesFlag.Name)
+	configs := []*node.Config{&cfg}
+	if cfg.GenesisFile == "" {
+		cfg.GenesisFile = filepath.Join(cfg.DataPath, "genesis.json")
+	}
+	if nodes > 1 {
+		configs = make([]*node.Config, nodes)
+		for i := range configs {
+			nodeCfg := cfg
+			nodeCfg.DataPath = filepath.Join(cfg.DataPath, fmt.Sprintf("node-%d", i+1))
+			nodeCfg.WalletPath = filepath.Join(nodeCfg.DataPath, "wallet")
+			configs[i] = &nodeCfg
+		}
+	}
+	b, err := newDevnetBuild(cfg.DataPath)
+	if err != nil {
 		return err
 	}
+	// nothing is left behind unless the build is committed
+	defer b.abort()
+	for _, nodeCfg := range configs {
+		if err := b.mkdirAll(nodeCfg.DataPath); err != nil {
+			return err
+		}
+		if err := b.mkdirAll(nodeCfg.WalletPath); err != nil {
+			return err
+		}
+	}
 
-	// 4: Generate Producer,
-	if err := createDevProducer(&cfg); err != nil {
+	// 4: Generate Producers,
+	password, err := readProducerPassword(ctx)
+	if err != nil {
+		return err
+	}
+	mnemonics, err := readProducerMnemonics(ctx, len(configs))
+	if err != nil {
 		return err
 	}
+	producers := make([]types.Address, len(configs))
+	for i, nodeCfg := range configs {
+		nodePassword := password
+		if nodePassword == "" {
+			if nodePassword, err = randomPassword(); err != nil {
+				return err
+			}
+		}
+		if mnemonics[i], err = createDevProducer(b, nodeCfg, nodePassword, mnemonics[i]); err != nil {
+			return fmt.Errorf("failed to create producer: %w", err)
+		}
+		if producers[i], err = types.ParseAddress(nodeCfg.Producer.Address); err != nil {
+			return err
+		}
+	}
 
 	// 5. Generate NetConfig
-	// TODO add flag for IP address to generate seeders to share with others
-	if err := createDevNet(&cfg); err != nil {
-		return err
+	basePort := cfg.Net.ListenPort
+	if ctx.IsSet(BasePortFlag.Name) {
+		basePort = ctx.Int(BasePortFlag.Name)
+	}
+	enodes := make([]string, len(configs))
+	for i, nodeCfg := range configs {
+		if nodes > 1 || ctx.IsSet(BasePortFlag.Name) {
+			port := basePort + i*devnetPortStride
+			nodeCfg.Net.ListenPort = port
+			nodeCfg.RPC.HTTPPort = port + devnetHTTPPortOffset
+			nodeCfg.RPC.WSPort = port + devnetWSPortOffset
+		}
+		enode, err := createDevNet(b, nodeCfg, ctx.String(HostFlag.Name))
+		if err != nil {
+			return fmt.Errorf("failed to create node key: %w", err)
+		}
+		enodes[i] = enode
+	}
+	// every node seeds from all the others
+	for i, nodeCfg := range configs {
+		for j, enode := range enodes {
+			if i != j {
+				nodeCfg.Net.Seeders = append(nodeCfg.Net.Seeders, enode)
+			}
+		}
 	}
 
 	// 6. Generate Genesis Config
-	if err := createDevGenesis(ctx, &cfg); err != nil {
+	gen, err := createDevGenesis(template, producers)
+	if err != nil {
 		return err
 	}
+	if err := b.writeJSON(cfg.GenesisFile, gen, 0644); err != nil {
+		return fmt.Errorf("failed to write genesis: %w", err)
+	}
 
 	// write config
-	configPath := filepath.Join(cfg.DataPath, "config.json")
-	file, _ := json.MarshalIndent(cfg, "", " ")
-	_ = os.WriteFile(configPath, file, 0700)
+	for _, nodeCfg := range configs {
+		nodeCfg.GenesisFile = cfg.GenesisFile
+		// holds the producer password
+		if err := b.writeJSON(filepath.Join(nodeCfg.DataPath, "config.json"), nodeCfg, 0600); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+	}
+
+	// 7. Validate before moving the devnet into place
+	if err := validateDevnetBuild(b, cfg.GenesisFile, configs); err != nil {
+		return fmt.Errorf("generated devnet is invalid: %w", err)
+	}
+
+	// the mnemonics are not stored anywhere else
+	output := ctx.String(ProducerMnemonicOutputFlag.Name)
+	if !ctx.IsSet(ProducerMnemonicFileFlag.Name) && output != "" {
+		if err := b.writeExternalFile(output, []byte(strings.Join(mnemonics, "\n")+"\n"), 0600); err != nil {
+			return fmt.Errorf("failed to write producer mnemonics: %w", err)
+		}
+	}
+
+	if err := b.commit(); err != nil {
+		return err
+	}
+
+	if !ctx.IsSet(ProducerMnemonicFileFlag.Name) {
+		if output != "" {
+			fmt.Println("Producer mnemonics written to", output)
+		} else {
+			fmt.Println("Write down the producer mnemonics, they are only shown once:")
+			for i, mnemonic := range mnemonics {
+				fmt.Printf("%s: %s\n", producers[i], mnemonic)
+			}
+		}
+	}
 
 	return nil
 }
 
-func checkCreatePaths(cfg *node.Config) error {
+// devnetBuild stages the files of a devnet in a temporary directory next
+// to its data path, which is renamed into place once everything has been
+// written. Files outside the data path are written directly and removed
+// again if the build is aborted.
+type devnetBuild struct {
+	dataPath  string
+	tmpPath   string
+	external  []string
+	committed bool
+}
+
+func newDevnetBuild(dataPath string) (*devnetBuild, error) {
 	// Abort if datapath already exists
-	if _, err := os.Stat(cfg.DataPath); err == nil {
-		return errors.New("datapath already exists")
+	if _, err := os.Stat(dataPath); err == nil {
+		return nil, errors.New("datapath already exists")
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	parent := filepath.Dir(dataPath)
+	if err := os.MkdirAll(parent, 0700); err != nil {
+		return nil, err
+	}
+	tmpPath, err := os.MkdirTemp(parent, "."+filepath.Base(dataPath)+".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	return &devnetBuild{dataPath: dataPath, tmpPath: tmpPath}, nil
+}
+
+// path returns where the file at its final path p is staged.
+func (b *devnetBuild) path(p string) string {
+	rel, err := filepath.Rel(b.dataPath, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	return filepath.Join(b.tmpPath, rel)
+}
+
+func (b *devnetBuild) staged(p string) bool {
+	return b.path(p) != p
+}
+
+func (b *devnetBuild) mkdirAll(p string) error {
+	return os.MkdirAll(b.path(p), 0700)
+}
+
+// created records a file written outside the data path.
+func (b *devnetBuild) created(p string) {
+	if !b.staged(p) {
+		b.external = append(b.external, p)
+	}
+}
+
+func (b *devnetBuild) writeExternalFile(p string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	b.external = append(b.external, p)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (b *devnetBuild) writeJSON(p string, v interface{}, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", " ")
+	if err != nil {
+		return err
+	}
+	if !b.staged(p) {
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return err
+		}
+		return b.writeExternalFile(p, data, perm)
 	}
-	if err := os.MkdirAll(cfg.DataPath, 0700); err != nil {
+	return os.WriteFile(b.path(p), data, perm)
+}
+
+func (b *devnetBuild) commit() error {
+	if err := os.Rename(b.tmpPath, b.dataPath); err != nil {
 		return err
 	}
-	if err := os.MkdirAll(cfg.WalletPath, 0700); err != nil {
+	b.committed = true
+	return nil
+}
+
+func (b *devnetBuild) abort() {
+	if b.committed {
+		return
+	}
+	_ = os.RemoveAll(b.tmpPath)
+	for _, p := range b.external {
+		_ = os.Remove(p)
+	}
+}
+
+// validateDevnetBuild reads back the staged genesis and checks that every
+// producer keystore decrypts to its configured address.
+func validateDevnetBuild(b *devnetBuild, genesisFile string, configs []*node.Config) error {
+	data, err := os.ReadFile(b.path(genesisFile))
+	if err != nil {
 		return err
 	}
+	gen := &genesis.GenesisConfig{}
+	if err := json.Unmarshal(data, gen); err != nil {
+		return fmt.Errorf("genesis: %w", err)
+	}
+	if problems := checkGenesis(gen); len(problems) > 0 {
+		return fmt.Errorf("genesis: %s", strings.Join(problems, ", "))
+	}
+	for _, nodeCfg := range configs {
+		producer := nodeCfg.Producer
+		kf, err := wallet.ReadKeyFile(b.path(producer.KeyFilePath))
+		if err != nil {
+			return fmt.Errorf("producer keyfile: %w", err)
+		}
+		ks, err := kf.Decrypt(producer.Password)
+		if err != nil {
+			return fmt.Errorf("producer keyfile: %w", err)
+		}
+		if ks.BaseAddress.String() != producer.Address {
+			return fmt.Errorf("producer keyfile %s does not match address %s", producer.KeyFilePath, producer.Address)
+		}
+	}
 	return nil
 }
 
-func createDevProducer(cfg *node.Config) error {
-	entropy, _ := bip39.NewEntropy(256)
-	mnemonic, _ := bip39.NewMnemonic(entropy)
+// readProducerPassword returns the password set by flag or file, or an
+// empty string when a random password should be generated per producer.
+func readProducerPassword(ctx *cli.Context) (string, error) {
+	if ctx.IsSet(ProducerPasswordFlag.Name) {
+		return ctx.String(ProducerPasswordFlag.Name), nil
+	}
+	if path := ctx.String(ProducerPasswordFileFlag.Name); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		password := strings.TrimRight(string(data), "\r\n")
+		if password == "" {
+			return "", errors.New("producer-password-file is empty")
+		}
+		return password, nil
+	}
+	return "", nil
+}
+
+// readProducerMnemonics returns the mnemonics to import for n producers,
+// empty strings when new ones should be generated.
+func readProducerMnemonics(ctx *cli.Context, n int) ([]string, error) {
+	mnemonics := make([]string, n)
+	path := ctx.String(ProducerMnemonicFileFlag.Name)
+	if path == "" {
+		return mnemonics, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0, n)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != n {
+		return nil, fmt.Errorf("producer-mnemonic-file has %d mnemonic(s), expected one per node (%d)", len(lines), n)
+	}
+	for i, mnemonic := range lines {
+		if !bip39.IsMnemonicValid(mnemonic) {
+			return nil, fmt.Errorf("producer-mnemonic-file line %d is not a valid mnemonic", i+1)
+		}
+		mnemonics[i] = mnemonic
+	}
+	return mnemonics, nil
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// createDevProducer creates the producer keystore of a node from mnemonic,
+// or from a new one when it is empty, and returns the mnemonic used.
+func createDevProducer(b *devnetBuild, cfg *node.Config, password string, mnemonic string) (string, error) {
+	var entropy []byte
+	var err error
+	if mnemonic == "" {
+		if entropy, err = bip39.NewEntropy(256); err != nil {
+			return "", err
+		}
+		if mnemonic, err = bip39.NewMnemonic(entropy); err != nil {
+			return "", err
+		}
+	} else if entropy, err = bip39.EntropyFromMnemonic(mnemonic); err != nil {
+		return "", err
+	}
 
 	ks := &wallet.KeyStore{
 		Entropy:  entropy,
 		Seed:     bip39.NewSeed(mnemonic, ""),
 		Mnemonic: mnemonic,
 	}
-	_, kp, _ := ks.DeriveForIndexPath(0)
+	_, kp, err := ks.DeriveForIndexPath(0)
+	if err != nil {
+		return "", err
+	}
 	ks.BaseAddress = kp.Address
 
-	// TODO make this random
-	password := "Don'tTrust.Verify"
-	kf, _ := ks.Encrypt(password)
-	kf.Path = filepath.Join(cfg.WalletPath, ks.BaseAddress.String())
-	kf.Write()
+	kf, err := ks.Encrypt(password)
+	if err != nil {
+		return "", err
+	}
+	keyFilePath := filepath.Join(cfg.WalletPath, ks.BaseAddress.String())
+	if _, err := os.Stat(keyFilePath); err == nil {
+		return "", fmt.Errorf("keyfile %s already exists", keyFilePath)
+	}
+	kf.Path = b.path(keyFilePath)
+	if err := kf.Write(); err != nil {
+		return "", err
+	}
+	b.created(keyFilePath)
 
 	producer := node.ProducerConfig{
 		Address:     kp.Address.String(),
 		Index:       0,
-		KeyFilePath: kf.Path,
+		KeyFilePath: keyFilePath,
 		Password:    password,
 	}
 
 	cfg.Producer = &producer
 
-	return nil
+	return mnemonic, nil
 }
 
-func createDevNet(cfg *node.Config) error {
-	// generate network key
-	// ask for ip address via flag??
-
+// createDevNet generates the network key of a node and returns the enode
+// URL other nodes use to reach it on host.
+func createDevNet(b *devnetBuild, cfg *node.Config, host string) (string, error) {
 	privateKeyFile := filepath.Join(cfg.DataPath, p2p.DefaultNetPrivateKeyFile)
 
 	key, err := crypto.GenerateKey()
 	if err != nil {
-		log.Crit("Failed to generate node key", "reason", err)
+		return "", err
 	}
-
-	if err := crypto.SaveECDSA(privateKeyFile, key); err != nil {
-		log.Error("Failed to persist node key", "reason", err)
+	if err := crypto.SaveECDSA(b.path(privateKeyFile), key); err != nil {
+		return "", err
 	}
 
 	cfg.Net.MinPeers = 0
 	cfg.Net.MinConnectedPeers = 0
 	cfg.Net.Seeders = []string{}
-	return nil
+
+	enode := fmt.Sprintf("enode://%x@%s", crypto.FromECDSAPub(&key.PublicKey)[1:], net.JoinHostPort(host, strconv.Itoa(cfg.Net.ListenPort)))
+	return enode, nil
 }
 
 func validateDevnetFlags(ctx *cli.Context) error {
-	if ctx.IsSet(GenesisBlockFlag.Name) {
-		input := ctx.StringSlice(GenesisBlockFlag.Name)
-		exists := make(map[types.Address]bool)
-		for _, s := range input {
-
-			ss := strings.Split(s, "/")
-			if len(ss) != 3 {
-				return errors.New("genesis-block flags must be in the format --genesis-block=<address>/<znnAmount>/<qsrAmount>")
-			}
-
-			a, err := types.ParseAddress(ss[0])
-			if err != nil {
-				return err
-			}
-			if types.IsEmbeddedAddress(a) {
-				return errors.New("genesis-block flag can only be set for user addresses")
-			}
+	nodes := ctx.Int(NodesFlag.Name)
+	if nodes < 1 {
+		return errors.New("nodes must be at least 1")
+	}
+	if nodes > 1 && ctx.IsSet(WalletDirFlag.Name) {
+		return errors.New("wallet cannot be set when generating multiple nodes, each node uses DataPath/node-<n>/wallet")
+	}
+	if ctx.IsSet(BasePortFlag.Name) {
+		port := ctx.Int(BasePortFlag.Name)
+		if port < 1 || port+(nodes-1)*devnetPortStride+devnetWSPortOffset > 65535 {
+			return errors.New("base-port leaves no room for the ports of all nodes")
+		}
+	}
+	if net.ParseIP(ctx.String(HostFlag.Name)) == nil {
+		return errors.New("host must be an IP address")
+	}
+	if ctx.IsSet(ProducerPasswordFlag.Name) && ctx.IsSet(ProducerPasswordFileFlag.Name) {
+		return errors.New("producer-password and producer-password-file cannot both be set")
+	}
+	if ctx.IsSet(ProducerPasswordFlag.Name) && ctx.String(ProducerPasswordFlag.Name) == "" {
+		return errors.New("producer-password cannot be empty")
+	}
+	if ctx.IsSet(ProducerMnemonicFileFlag.Name) && ctx.IsSet(ProducerMnemonicOutputFlag.Name) {
+		return errors.New("producer-mnemonic-output cannot be used with imported mnemonics")
+	}
 
-			z, err := strconv.ParseUint(ss[1], 10, 64)
-			if err != nil {
-				return err
-			}
-			q, err := strconv.ParseUint(ss[2], 10, 64)
-			if err != nil {
-				return err
-			}
+	return nil
+}
 
-			if z == 0 && q == 0 {
-				return errors.New("genesis-block znn and qsr amount cannot both be 0")
-			}
-			// TODO maximum? and check for total token supply exceeds cap
+// createDevGenesis returns the genesis described by t with one pillar for
+// every producer. The total supplies are the sums of all genesis balances.
+func createDevGenesis(t *genesisTemplate, producers []types.Address) (*genesis.GenesisConfig, error) {
+	gen := &genesis.GenesisConfig{
+		ChainIdentifier:     t.ChainId,
+		ExtraData:           t.ExtraData,
+		GenesisTimestampSec: t.GenesisTimestampSec,
 
-			if _, ok := exists[a]; ok {
-				return errors.New("genesis-block addresses must be unique")
-			}
-			exists[a] = true
+		PillarConfig: &genesis.PillarContractConfig{
+			Delegations:   []*definition.DelegationInfo{},
+			LegacyEntries: []*definition.LegacyPillarEntry{},
+			Pillars:       []*definition.PillarInfo{},
+		},
+		TokenConfig: &genesis.TokenContractConfig{
+			Tokens: []*definition.TokenInfo{}},
+		PlasmaConfig: &genesis.PlasmaContractConfig{
+			Fusions: []*definition.FusionInfo{}},
+		SwapConfig: &genesis.SwapContractConfig{
+			Entries: []*definition.SwapAssets{}},
+		SporkConfig: &genesis.SporkConfig{
+			Sporks: []*definition.Spork{}},
+		GenesisBlocks: &genesis.GenesisBlocksConfig{
+			Blocks: []*genesis.GenesisBlockConfig{}},
+	}
+	if t.ChainId == 0 {
+		return nil, errors.New("chain-id cannot be 0")
+	}
+	if t.GenesisTimestampSec < 0 {
+		return nil, errors.New("genesis timestamp must be after 1970")
+	}
+	if gen.GenesisTimestampSec == 0 {
+		gen.GenesisTimestampSec = time.Now().Unix()
+	}
+	addBlock := func(address types.Address, znn, qsr *big.Int) {
+		balances := make(map[types.ZenonTokenStandard]*big.Int)
+		if znn != nil {
+			balances[types.ZnnTokenStandard] = znn
 		}
+		if qsr != nil {
+			balances[types.QsrTokenStandard] = qsr
+		}
+		gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &genesis.GenesisBlockConfig{
+			Address:     address,
+			BalanceList: balances,
+		})
 	}
 
-	if ctx.IsSet(GenesisFusionFlag.Name) {
-		input := ctx.StringSlice(GenesisFusionFlag.Name)
-		exists := make(map[types.Address]bool)
-		for _, s := range input {
+	// spork address
+	if t.SporkAddress != "" {
+		a, err := parseGenesisUserAddress("spork-address", t.SporkAddress)
+		if err != nil {
+			return nil, err
+		}
+		gen.SporkAddress = &a
+	} else if len(producers) > 0 {
+		gen.SporkAddress = &producers[0]
+	}
 
-			ss := strings.Split(s, "/")
-			if len(ss) != 2 {
-				return errors.New("genesis-fusion flags must be in the format --genesis-fusion=<address>/<qsrAmount>")
+	// by default activate all implemented sporks at height 0
+	// can be overriden by the template and --genesis-spork
+	sporkIds := make([]types.Hash, 0, len(types.ImplementedSporksMap))
+	for sporkId := range types.ImplementedSporksMap {
+		sporkIds = append(sporkIds, sporkId)
+	}
+	// sorted so the same input always produces the same genesis
+	sort.Slice(sporkIds, func(i, j int) bool {
+		return bytes.Compare(sporkIds[i].Bytes(), sporkIds[j].Bytes()) < 0
+	})
+	for _, sporkId := range sporkIds {
+		gen.SporkConfig.Sporks = append(gen.SporkConfig.Sporks, &definition.Spork{
+			Id:                sporkId,
+			Name:              "genesis-spork",
+			Description:       "genesis-spork",
+			Activated:         types.ImplementedSporksMap[sporkId],
+			EnforcementHeight: 0,
+		})
+	}
+	sporksSet := make(map[types.Hash]bool)
+	for _, st := range t.Sporks {
+		spork, err := st.spork()
+		if err != nil {
+			return nil, err
+		}
+		if sporksSet[spork.Id] {
+			return nil, fmt.Errorf("genesis spork %v is set more than once", spork.Id)
+		}
+		sporksSet[spork.Id] = true
+		replaced := false
+		for i, existing := range gen.SporkConfig.Sporks {
+			if existing.Id == spork.Id {
+				gen.SporkConfig.Sporks[i] = spork
+				replaced = true
 			}
+		}
+		if !replaced {
+			gen.SporkConfig.Sporks = append(gen.SporkConfig.Sporks, spork)
+		}
+	}
 
-			a, err := types.ParseAddress(ss[0])
-			if err != nil {
-				return err
-			}
-			if types.IsEmbeddedAddress(a) {
-				return errors.New("genesis-fusion flag can only be set for user addresses")
+	// pillars of the generated nodes followed by the template pillars
+	pillarContractZnn := big.NewInt(0)
+	pillarNames := make(map[string]bool)
+	producerSet := make(map[types.Address]bool)
+	addPillar := func(name string, producer, stake, reward types.Address, amount *big.Int) error {
+		if !pillarNameRegexp.MatchString(name) || len(name) > pillarNameMaxLength {
+			return fmt.Errorf("invalid genesis pillar name %q", name)
+		}
+		if pillarNames[name] {
+			return fmt.Errorf("genesis pillar name %q is used more than once", name)
+		}
+		if producerSet[producer] {
+			return fmt.Errorf("genesis pillar producer %v is used more than once", producer)
+		}
+		pillarNames[name] = true
+		producerSet[producer] = true
+		gen.PillarConfig.Pillars = append(gen.PillarConfig.Pillars, &definition.PillarInfo{
+			Name:                         name,
+			Amount:                       amount,
+			BlockProducingAddress:        producer,
+			StakeAddress:                 stake,
+			RewardWithdrawAddress:        reward,
+			PillarType:                   1,
+			RevokeTime:                   0,
+			GiveBlockRewardPercentage:    0,
+			GiveDelegateRewardPercentage: 100,
+		})
+		pillarContractZnn.Add(pillarContractZnn, amount)
+		return nil
+	}
+	for i, producer := range producers {
+		name := "Local"
+		if len(producers) > 1 {
+			name = fmt.Sprintf("Local-%d", i+1)
+		}
+		if err := addPillar(name, producer, producer, producer, new(big.Int).Set(constants.PillarStakeAmount)); err != nil {
+			return nil, err
+		}
+	}
+	for _, pt := range t.Pillars {
+		producer, err := parseGenesisUserAddress("genesis pillar producer", pt.Producer)
+		if err != nil {
+			return nil, err
+		}
+		stake, reward := producer, producer
+		if pt.Stake != "" {
+			if stake, err = parseGenesisUserAddress("genesis pillar stake", pt.Stake); err != nil {
+				return nil, err
 			}
-
-			q, err := strconv.ParseUint(ss[1], 10, 64)
-			if err != nil {
-				return err
+		}
+		if pt.Reward != "" {
+			if reward, err = parseGenesisUserAddress("genesis pillar reward", pt.Reward); err != nil {
+				return nil, err
 			}
-
-			if q == 0 || q > 5000 {
-				return errors.New("genesis-fusion amount must be between min:1 max:5000")
+		}
+		amount := new(big.Int).Set(constants.PillarStakeAmount)
+		if pt.Znn != "" {
+			if amount, err = parseAmount(pt.Znn, ZnnDecimals); err != nil {
+				return nil, err
 			}
-
-			if _, ok := exists[a]; ok {
-				return errors.New("genesis-fusion addresses must be unique")
+			if amount.Sign() == 0 {
+				return nil, fmt.Errorf("genesis pillar %q amount cannot be 0", pt.Name)
 			}
-			exists[a] = true
+		}
+		if err := addPillar(pt.Name, producer, stake, reward, amount); err != nil {
+			return nil, err
 		}
 	}
-	return nil
-}
-
-func createDevGenesis(ctx *cli.Context, cfg *node.Config) error {
-	if cfg.GenesisFile == "" {
-		cfg.GenesisFile = filepath.Join(cfg.DataPath, "genesis.json")
+	if pillarContractZnn.Sign() > 0 {
+		addBlock(types.PillarContract, pillarContractZnn, nil)
 	}
 
-	localPillar, _ := types.ParseAddress(cfg.Producer.Address)
-
-	znnStandard := definition.TokenInfo{
-		Decimals:      8,
-		IsBurnable:    true,
-		IsMintable:    true,
-		IsUtility:     true,
-		MaxSupply:     big.NewInt(9007199254740991),
-		Owner:         types.TokenContract,
-		TokenDomain:   "biginches.club",
-		TokenName:     "tZNN",
-		TokenStandard: types.ZnnTokenStandard,
-		TokenSymbol:   "tZNN",
-		TotalSupply:   big.NewInt(78713599988800),
-	}
-	qsrStandard := definition.TokenInfo{
-		Decimals:      8,
-		IsBurnable:    true,
-		IsMintable:    true,
-		IsUtility:     true,
-		MaxSupply:     big.NewInt(9007199254740991),
-		Owner:         types.TokenContract,
-		TokenDomain:   "biginches.club",
-		TokenName:     "tQSR",
-		TokenStandard: types.QsrTokenStandard,
-		TokenSymbol:   "tQSR",
-		TotalSupply:   big.NewInt(772135999888000),
+	legacySet := make(map[types.Hash]bool)
+	for _, lt := range t.LegacyPillars {
+		keyIdHash, err := types.HexToHash(lt.KeyIdHash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid genesis legacy pillar key id hash %q: %w", lt.KeyIdHash, err)
+		}
+		if lt.PillarCount == 0 {
+			return nil, fmt.Errorf("genesis legacy pillar %v needs a pillar count", keyIdHash)
+		}
+		if legacySet[keyIdHash] {
+			return nil, fmt.Errorf("genesis legacy pillar key id hash %v is used more than once", keyIdHash)
+		}
+		legacySet[keyIdHash] = true
+		gen.PillarConfig.LegacyEntries = append(gen.PillarConfig.LegacyEntries, &definition.LegacyPillarEntry{
+			KeyIdHash:   keyIdHash,
+			PillarCount: lt.PillarCount,
+		})
 	}
 
-	// by default activate all implemented sporks at height 0
-	// can be overriden by --genesis-spork
-	genesisSporksMap := make(map[types.Hash]bool)
-	for sporkId, status := range types.ImplementedSporksMap {
-		genesisSporksMap[sporkId] = status
-	}
-	// apply genesis sporks flag
-	genesisSporks := make([]*definition.Spork, 0)
-	for sporkId, status := range genesisSporksMap {
-		spork := definition.Spork{
-			Id:                sporkId,
-			Name:              "genesis-spork",
-			Description:       "genesis-spork",
-			Activated:         status,
-			EnforcementHeight: 0,
+	backers := make(map[types.Address]bool)
+	for _, dt := range t.Delegations {
+		backer, err := parseGenesisUserAddress("genesis delegation", dt.Address)
+		if err != nil {
+			return nil, err
+		}
+		if !pillarNames[dt.Pillar] {
+			return nil, fmt.Errorf("genesis delegation to unknown pillar %q", dt.Pillar)
+		}
+		if backers[backer] {
+			return nil, fmt.Errorf("genesis delegation address %v is used more than once", backer)
 		}
-		genesisSporks = append(genesisSporks, &spork)
+		backers[backer] = true
+		gen.PillarConfig.Delegations = append(gen.PillarConfig.Delegations, &definition.DelegationInfo{
+			Backer: backer,
+			Name:   dt.Pillar,
+		})
 	}
 
-	gen := genesis.GenesisConfig{
-		ChainIdentifier:     321,
-		ExtraData:           "/thank_you_bich_dao",
-		GenesisTimestampSec: time.Now().Unix(),
-		SporkAddress:        &localPillar,
-
-		PillarConfig: &genesis.PillarContractConfig{
-			Delegations:   []*definition.DelegationInfo{},
-			LegacyEntries: []*definition.LegacyPillarEntry{},
-			Pillars: []*definition.PillarInfo{
-				{
-					Name:                         "Local",
-					Amount:                       big.NewInt(1500000000000),
-					BlockProducingAddress:        localPillar,
-					StakeAddress:                 localPillar,
-					RewardWithdrawAddress:        localPillar,
-					PillarType:                   1,
-					RevokeTime:                   0,
-					GiveBlockRewardPercentage:    0,
-					GiveDelegateRewardPercentage: 100,
-				},
-			}},
-		TokenConfig: &genesis.TokenContractConfig{
-			Tokens: []*definition.TokenInfo{
-				&znnStandard,
-				&qsrStandard,
-			}},
-		PlasmaConfig: &genesis.PlasmaContractConfig{
-			Fusions: []*definition.FusionInfo{}},
-		SwapConfig: &genesis.SwapContractConfig{
-			Entries: []*definition.SwapAssets{}},
-		SporkConfig: &genesis.SporkConfig{
-			Sporks: genesisSporks,
-		},
-		GenesisBlocks: &genesis.GenesisBlocksConfig{
-			Blocks: []*genesis.GenesisBlockConfig{
-				{
-					Address: types.PillarContract,
-					BalanceList: map[types.ZenonTokenStandard]*big.Int{
-						types.ZnnTokenStandard: big.NewInt(1500000000000),
-					},
-				},
-				{
-					Address: types.AcceleratorContract,
-					BalanceList: map[types.ZenonTokenStandard]*big.Int{
-						types.ZnnTokenStandard: big.NewInt(77213599988800),
-						types.QsrTokenStandard: big.NewInt(772135999888000),
-					},
-				},
-			},
-		}}
-
-	if ctx.IsSet(GenesisBlockFlag.Name) {
-		input := ctx.StringSlice(GenesisBlockFlag.Name)
-		for _, s := range input {
-
-			ss := strings.Split(s, "/")
-			a, _ := types.ParseAddress(ss[0])
-			z, _ := strconv.ParseInt(ss[1], 10, 64)
-			q, _ := strconv.ParseInt(ss[2], 10, 64)
-			znn := big.NewInt(z * constants.Decimals)
-			qsr := big.NewInt(q * constants.Decimals)
+	// accelerator funding
+	acceleratorZnn, err := parseGenesisAmount(t.Accelerator.Znn, ZnnDecimals)
+	if err != nil {
+		return nil, err
+	}
+	acceleratorQsr, err := parseGenesisAmount(t.Accelerator.Qsr, QsrDecimals)
+	if err != nil {
+		return nil, err
+	}
+	if acceleratorZnn.Sign() > 0 || acceleratorQsr.Sign() > 0 {
+		addBlock(types.AcceleratorContract, acceleratorZnn, acceleratorQsr)
+	}
 
-			znnStandard.TotalSupply.Add(znnStandard.TotalSupply, znn)
-			qsrStandard.TotalSupply.Add(qsrStandard.TotalSupply, qsr)
-			block := genesis.GenesisBlockConfig{
-				Address: a,
-				BalanceList: map[types.ZenonTokenStandard]*big.Int{
-					types.ZnnTokenStandard: znn,
-					types.QsrTokenStandard: qsr,
-				},
-			}
-			gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &block)
+	balancesSet := make(map[types.Address]*genesis.GenesisBlockConfig)
+	for _, bt := range t.Balances {
+		a, err := parseGenesisUserAddress("genesis-block", bt.Address)
+		if err != nil {
+			return nil, err
+		}
+		znn, err := parseGenesisAmount(bt.Znn, ZnnDecimals)
+		if err != nil {
+			return nil, err
+		}
+		qsr, err := parseGenesisAmount(bt.Qsr, QsrDecimals)
+		if err != nil {
+			return nil, err
+		}
+		if znn.Sign() == 0 && qsr.Sign() == 0 {
+			return nil, errors.New("genesis-block znn and qsr amount cannot both be 0")
+		}
+		if _, ok := balancesSet[a]; ok {
+			return nil, errors.New("genesis-block addresses must be unique")
+		}
+		addBlock(a, znn, qsr)
+		balancesSet[a] = gen.GenesisBlocks.Blocks[len(gen.GenesisBlocks.Blocks)-1]
+	}
 
+	// sentinel funds are added on top of the genesis-block balance
+	sentinelsSet := make(map[types.Address]bool)
+	for _, sentinel := range t.SentinelFunds {
+		a, err := parseGenesisUserAddress("genesis-sentinel-funds", sentinel)
+		if err != nil {
+			return nil, err
+		}
+		if sentinelsSet[a] {
+			return nil, fmt.Errorf("genesis-sentinel-funds %v is set more than once", a)
+		}
+		sentinelsSet[a] = true
+		if block, ok := balancesSet[a]; ok {
+			block.BalanceList[types.ZnnTokenStandard] = new(big.Int).Add(block.BalanceList[types.ZnnTokenStandard], constants.SentinelZnnRegisterAmount)
+			block.BalanceList[types.QsrTokenStandard] = new(big.Int).Add(block.BalanceList[types.QsrTokenStandard], constants.SentinelQsrDepositAmount)
+			continue
 		}
+		addBlock(a, new(big.Int).Set(constants.SentinelZnnRegisterAmount), new(big.Int).Set(constants.SentinelQsrDepositAmount))
 	}
 
-	if ctx.IsSet(GenesisFusionFlag.Name) {
-		plasmaAddress := big.NewInt(0)
-		input := ctx.StringSlice(GenesisFusionFlag.Name)
-		for _, s := range input {
+	plasmaContractQsr := big.NewInt(0)
+	fusionsSet := make(map[types.Address]bool)
+	for _, ft := range t.Fusions {
+		a, err := parseGenesisUserAddress("genesis-fusion", ft.Address)
+		if err != nil {
+			return nil, err
+		}
+		qsr, err := parseAmount(ft.Qsr, QsrDecimals)
+		if err != nil {
+			return nil, err
+		}
+		if qsr.Cmp(genesisFusionMinAmount) < 0 || qsr.Cmp(genesisFusionMaxAmount) > 0 {
+			return nil, errors.New("genesis-fusion amount must be between min:1 max:5000 QSR")
+		}
+		if fusionsSet[a] {
+			return nil, errors.New("genesis-fusion addresses must be unique")
+		}
+		fusionsSet[a] = true
+		plasmaContractQsr.Add(plasmaContractQsr, qsr)
+		gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &definition.FusionInfo{
+			Owner:            a,
+			Id:               types.NewHash(a.Bytes()),
+			Amount:           qsr,
+			ExpirationHeight: 1,
+			Beneficiary:      a,
+		})
+	}
+	if plasmaContractQsr.Sign() > 0 {
+		addBlock(types.PlasmaContract, nil, plasmaContractQsr)
+	}
 
-			ss := strings.Split(s, "/")
-			a, _ := types.ParseAddress(ss[0])
-			q, _ := strconv.ParseInt(ss[1], 10, 64)
-			qsr := big.NewInt(q * constants.Decimals)
+	swapContractZnn, swapContractQsr := big.NewInt(0), big.NewInt(0)
+	swapSet := make(map[types.Hash]bool)
+	for _, st := range t.Swap {
+		keyIdHash, err := types.HexToHash(st.KeyIdHash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid genesis swap key id hash %q: %w", st.KeyIdHash, err)
+		}
+		znn, err := parseGenesisAmount(st.Znn, ZnnDecimals)
+		if err != nil {
+			return nil, err
+		}
+		qsr, err := parseGenesisAmount(st.Qsr, QsrDecimals)
+		if err != nil {
+			return nil, err
+		}
+		if swapSet[keyIdHash] {
+			return nil, fmt.Errorf("genesis swap key id hash %v is used more than once", keyIdHash)
+		}
+		swapSet[keyIdHash] = true
+		swapContractZnn.Add(swapContractZnn, znn)
+		swapContractQsr.Add(swapContractQsr, qsr)
+		gen.SwapConfig.Entries = append(gen.SwapConfig.Entries, &definition.SwapAssets{
+			KeyIdHash: keyIdHash,
+			Znn:       znn,
+			Qsr:       qsr,
+		})
+	}
+	if swapContractZnn.Sign() > 0 || swapContractQsr.Sign() > 0 {
+		addBlock(types.SwapContract, swapContractZnn, swapContractQsr)
+	}
 
+	// tokens
+	znnStandard, err := genesisToken(t.Znn, types.ZnnTokenStandard, ZnnDecimals)
+	if err != nil {
+		return nil, err
+	}
+	qsrStandard, err := genesisToken(t.Qsr, types.QsrTokenStandard, QsrDecimals)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range gen.GenesisBlocks.Blocks {
+		if znn, ok := block.BalanceList[types.ZnnTokenStandard]; ok {
+			znnStandard.TotalSupply.Add(znnStandard.TotalSupply, znn)
+		}
+		if qsr, ok := block.BalanceList[types.QsrTokenStandard]; ok {
 			qsrStandard.TotalSupply.Add(qsrStandard.TotalSupply, qsr)
-			plasmaAddress.Add(plasmaAddress, qsr)
-			fusion := definition.FusionInfo{
-				Owner:            a,
-				Id:               types.NewHash(a.Bytes()),
-				Amount:           qsr,
-				ExpirationHeight: 1,
-				Beneficiary:      a,
-			}
-			gen.PlasmaConfig.Fusions = append(gen.PlasmaConfig.Fusions, &fusion)
 		}
-		block := genesis.GenesisBlockConfig{
-			Address: types.PlasmaContract,
-			BalanceList: map[types.ZenonTokenStandard]*big.Int{
-				types.QsrTokenStandard: plasmaAddress,
-			},
+	}
+	for _, token := range []*definition.TokenInfo{znnStandard, qsrStandard} {
+		if token.TotalSupply.Cmp(token.MaxSupply) > 0 {
+			return nil, fmt.Errorf("%s total supply of %s exceeds its max supply of %s", token.TokenSymbol, formatAmount(token.TotalSupply, token.Decimals), formatAmount(token.MaxSupply, token.Decimals))
 		}
-		gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, &block)
-
 	}
+	gen.TokenConfig.Tokens = append(gen.TokenConfig.Tokens, znnStandard, qsrStandard)
 
-	file, _ := json.MarshalIndent(gen, "", " ")
-	_ = os.WriteFile(cfg.GenesisFile, file, 0644)
+	return gen, nil
+}
 
-	return nil
+func genesisToken(tt genesisTokenTemplate, zts types.ZenonTokenStandard, decimals uint8) (*definition.TokenInfo, error) {
+	if tt.Name == "" || tt.Symbol == "" {
+		return nil, fmt.Errorf("genesis token %v needs a name and a symbol", zts)
+	}
+	if len(tt.Name) > tokenNameMaxLength || len(tt.Symbol) > tokenSymbolMaxLength || len(tt.Domain) > tokenDomainMaxLength {
+		return nil, fmt.Errorf("genesis token %s name, symbol or domain is too long", tt.Symbol)
+	}
+	maxSupply, err := parseAmount(tt.MaxSupply, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s max supply: %w", tt.Symbol, err)
+	}
+	if maxSupply.Sign() == 0 || maxSupply.Cmp(tokenMaxSupplyLimit) > 0 {
+		return nil, fmt.Errorf("%s max supply must be greater than 0 and at most %s", tt.Symbol, formatAmount(tokenMaxSupplyLimit, decimals))
+	}
+	return &definition.TokenInfo{
+		Decimals:      decimals,
+		IsBurnable:    true,
+		IsMintable:    true,
+		IsUtility:     true,
+		MaxSupply:     maxSupply,
+		Owner:         types.TokenContract,
+		TokenDomain:   tt.Domain,
+		TokenName:     tt.Name,
+		TokenStandard: zts,
+		TokenSymbol:   tt.Symbol,
+		TotalSupply:   big.NewInt(0),
+	}, nil
 }
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/genesis"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/constants"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+// runGenesisFlags runs generate-devnet through the real app with its
+// action replaced by the genesis steps, so no files are written.
+func runGenesisFlags(t *testing.T, args ...string) (*genesis.GenesisConfig, error) {
+	t.Helper()
+	var gen *genesis.GenesisConfig
+	cmd := devnetCommand
+	cmd.Action = func(ctx *cli.Context) error {
+		template, err := readGenesisTemplate(ctx.String(GenesisTemplateFlag.Name))
+		if err != nil {
+			return err
+		}
+		if err := mergeGenesisFlags(ctx, template); err != nil {
+			return err
+		}
+		gen, err = createDevGenesis(template, []types.Address{testAddress(1)})
+		return err
+	}
+	// the flag value is shared between runs of the same process
+	*GenesisSporkFlag.Value.(*rawStringSlice) = nil
+	app := newApp()
+	app.Commands = []*cli.Command{&cmd}
+	err := app.Run(append([]string{"nomctl", cmd.Name}, args...))
+	return gen, err
+}
+
+func findGenesisSpork(gen *genesis.GenesisConfig, id types.Hash) *definition.Spork {
+	for _, s := range gen.SporkConfig.Sporks {
+		if s.Id == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestGenesisSporkFlag(t *testing.T) {
+	custom := types.NewHash([]byte("custom"))
+	var implemented types.Hash
+	for id := range types.ImplementedSporksMap {
+		implemented = id
+		break
+	}
+
+	gen, err := runGenesisFlags(t,
+		"--genesis-spork="+custom.String()+",true,10,custom,Enables a, b and c",
+		"--genesis-spork", implemented.String()+",false",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := findGenesisSpork(gen, custom)
+	if s == nil {
+		t.Fatal("custom spork missing")
+	}
+	if !s.Activated || s.EnforcementHeight != 10 || s.Name != "custom" || s.Description != "Enables a, b and c" {
+		t.Errorf("got custom spork %+v", s)
+	}
+	if s := findGenesisSpork(gen, implemented); s == nil || s.Activated {
+		t.Errorf("got implemented spork %+v, want it deactivated", s)
+	}
+	if len(gen.SporkConfig.Sporks) != len(types.ImplementedSporksMap)+1 {
+		t.Errorf("got %d sporks, want %d", len(gen.SporkConfig.Sporks), len(types.ImplementedSporksMap)+1)
+	}
+
+	for _, arg := range []string{
+		"--genesis-spork=" + custom.String(),
+		"--genesis-spork=" + custom.String() + ",yes",
+		"--genesis-spork=" + custom.String() + ",true,ten,custom",
+		"--genesis-spork=" + custom.String() + ",true",
+	} {
+		if _, err := runGenesisFlags(t, arg); err == nil {
+			t.Errorf("%s was accepted", arg)
+		}
+	}
+}
+
+func TestGenesisBlockFlagCommaList(t *testing.T) {
+	first, second := testAddress(2), testAddress(3)
+	gen, err := runGenesisFlags(t, "--genesis-block", first.String()+"/1/2,"+second.String()+"/3/4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, block := range gen.GenesisBlocks.Blocks {
+		if block.Address == first || block.Address == second {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("got %d of the 2 comma separated genesis blocks", found)
+	}
+}
+
+func TestCreateDevGenesis(t *testing.T) {
+	user, other := testAddress(2).String(), testAddress(3).String()
+	custom := types.NewHash([]byte("custom")).String()
+	legacy := types.NewHash([]byte("legacy")).String()
+
+	tests := []struct {
+		name    string
+		edit    func(*genesisTemplate)
+		wantErr string
+		check   func(*testing.T, *genesis.GenesisConfig)
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, gen *genesis.GenesisConfig) {
+				if len(gen.PillarConfig.Pillars) != 1 || gen.PillarConfig.Pillars[0].Name != "Local" {
+					t.Errorf("got pillars %+v", gen.PillarConfig.Pillars)
+				}
+				if gen.SporkAddress == nil || *gen.SporkAddress != testAddress(1) {
+					t.Errorf("got spork address %v, want the producer", gen.SporkAddress)
+				}
+				if len(gen.SporkConfig.Sporks) != len(types.ImplementedSporksMap) {
+					t.Errorf("got %d sporks, want the implemented ones", len(gen.SporkConfig.Sporks))
+				}
+				if len(checkGenesis(gen)) != 0 {
+					t.Errorf("default genesis fails its checks: %v", checkGenesis(gen))
+				}
+			},
+		},
+		{
+			name: "pillar, delegation, fusion and balance",
+			edit: func(gt *genesisTemplate) {
+				gt.Pillars = []genesisPillarTemplate{{Name: "Other", Producer: other}}
+				gt.Delegations = []genesisDelegationTemplate{{Address: user, Pillar: "Other"}}
+				gt.Fusions = []genesisFusionTemplate{{Address: user, Qsr: "10"}}
+				gt.Balances = []genesisBalanceTemplate{{Address: user, Znn: "1.5", Qsr: "0"}}
+			},
+			check: func(t *testing.T, gen *genesis.GenesisConfig) {
+				if len(gen.PillarConfig.Pillars) != 2 || len(gen.PillarConfig.Delegations) != 1 || len(gen.PlasmaConfig.Fusions) != 1 {
+					t.Errorf("got %d pillars, %d delegations and %d fusions", len(gen.PillarConfig.Pillars), len(gen.PillarConfig.Delegations), len(gen.PlasmaConfig.Fusions))
+				}
+				if len(checkGenesis(gen)) != 0 {
+					t.Errorf("genesis fails its checks: %v", checkGenesis(gen))
+				}
+			},
+		},
+		{
+			name: "sentinel funds and legacy pillars",
+			edit: func(gt *genesisTemplate) {
+				gt.Balances = []genesisBalanceTemplate{{Address: user, Znn: "1", Qsr: "2"}}
+				gt.SentinelFunds = []string{user, other}
+				gt.LegacyPillars = []genesisLegacyPillarTemplate{{KeyIdHash: legacy, PillarCount: 2}}
+			},
+			check: func(t *testing.T, gen *genesis.GenesisConfig) {
+				funds := map[types.Address][2]*big.Int{
+					testAddress(2): {new(big.Int).Add(big.NewInt(1e8), constants.SentinelZnnRegisterAmount), new(big.Int).Add(big.NewInt(2e8), constants.SentinelQsrDepositAmount)},
+					testAddress(3): {constants.SentinelZnnRegisterAmount, constants.SentinelQsrDepositAmount},
+				}
+				for _, block := range gen.GenesisBlocks.Blocks {
+					want, ok := funds[block.Address]
+					if !ok {
+						continue
+					}
+					delete(funds, block.Address)
+					if block.BalanceList[types.ZnnTokenStandard].Cmp(want[0]) != 0 || block.BalanceList[types.QsrTokenStandard].Cmp(want[1]) != 0 {
+						t.Errorf("got balances %v for %v, want %v", block.BalanceList, block.Address, want)
+					}
+				}
+				if len(funds) != 0 {
+					t.Errorf("no genesis blocks for %v", funds)
+				}
+				entries := gen.PillarConfig.LegacyEntries
+				if len(entries) != 1 || entries[0].KeyIdHash.String() != legacy || entries[0].PillarCount != 2 {
+					t.Errorf("got legacy entries %+v", entries)
+				}
+				if len(checkGenesis(gen)) != 0 {
+					t.Errorf("genesis fails its checks: %v", checkGenesis(gen))
+				}
+			},
+		},
+		{name: "duplicate sentinel funds", edit: func(gt *genesisTemplate) {
+			gt.SentinelFunds = []string{user, user}
+		}, wantErr: "more than once"},
+		{name: "duplicate legacy pillar", edit: func(gt *genesisTemplate) {
+			gt.LegacyPillars = []genesisLegacyPillarTemplate{{KeyIdHash: legacy, PillarCount: 1}, {KeyIdHash: legacy, PillarCount: 1}}
+		}, wantErr: "more than once"},
+		{name: "legacy pillar without pillars", edit: func(gt *genesisTemplate) {
+			gt.LegacyPillars = []genesisLegacyPillarTemplate{{KeyIdHash: legacy}}
+		}, wantErr: "pillar count"},
+		{name: "chain id 0", edit: func(gt *genesisTemplate) { gt.ChainId = 0 }, wantErr: "chain-id"},
+		{name: "negative timestamp", edit: func(gt *genesisTemplate) { gt.GenesisTimestampSec = -1 }, wantErr: "after 1970"},
+		{name: "duplicate pillar name", edit: func(gt *genesisTemplate) {
+			gt.Pillars = []genesisPillarTemplate{{Name: "Local", Producer: other}}
+		}, wantErr: "used more than once"},
+		{name: "duplicate producer", edit: func(gt *genesisTemplate) {
+			gt.Pillars = []genesisPillarTemplate{{Name: "Other", Producer: testAddress(1).String()}}
+		}, wantErr: "used more than once"},
+		{name: "delegation to unknown pillar", edit: func(gt *genesisTemplate) {
+			gt.Delegations = []genesisDelegationTemplate{{Address: user, Pillar: "Nobody"}}
+		}, wantErr: "unknown pillar"},
+		{name: "duplicate balance", edit: func(gt *genesisTemplate) {
+			gt.Balances = []genesisBalanceTemplate{{Address: user, Znn: "1"}, {Address: user, Qsr: "1"}}
+		}, wantErr: "must be unique"},
+		{name: "empty balance", edit: func(gt *genesisTemplate) {
+			gt.Balances = []genesisBalanceTemplate{{Address: user}}
+		}, wantErr: "cannot both be 0"},
+		{name: "embedded balance address", edit: func(gt *genesisTemplate) {
+			gt.Balances = []genesisBalanceTemplate{{Address: types.PillarContract.String(), Znn: "1"}}
+		}, wantErr: "user addresses"},
+		{name: "fusion over the maximum", edit: func(gt *genesisTemplate) {
+			gt.Fusions = []genesisFusionTemplate{{Address: user, Qsr: "5001"}}
+		}, wantErr: "genesis-fusion amount"},
+		{name: "unnamed custom spork", edit: func(gt *genesisTemplate) {
+			gt.Sporks = []genesisSporkTemplate{{Id: custom, Activated: true}}
+		}, wantErr: "give it a name"},
+		{name: "duplicate spork", edit: func(gt *genesisTemplate) {
+			gt.Sporks = []genesisSporkTemplate{{Id: custom, Name: "custom"}, {Id: custom, Name: "custom"}}
+		}, wantErr: "more than once"},
+		{name: "enforcement of an inactive spork", edit: func(gt *genesisTemplate) {
+			gt.Sporks = []genesisSporkTemplate{{Id: custom, Name: "custom", EnforcementHeight: 5}}
+		}, wantErr: "activated sporks"},
+		{name: "supply over the maximum", edit: func(gt *genesisTemplate) {
+			gt.Znn.MaxSupply = "100"
+		}, wantErr: "exceeds its max supply"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := defaultGenesisTemplate()
+			if tt.edit != nil {
+				tt.edit(template)
+			}
+			gen, err := createDevGenesis(template, []types.Address{testAddress(1)})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, gen)
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/constants"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+	"gopkg.in/yaml.v3"
+)
+
+// genesisTemplate describes a devnet genesis. It is read from the file given
+// with --genesis-template and the genesis flags are merged into it. Amounts
+// are in whole tokens and may be fractional, empty amounts are 0.
+//
+// The go-zenon genesis has no sentinel state, so sentinel addresses are
+// only funded and registered after genesis with znn-cli devnet.bootstrap.
+type genesisTemplate struct {
+	ChainId             uint64                        `yaml:"chainId"`
+	ExtraData           string                        `yaml:"extraData"`
+	GenesisTimestampSec int64                         `yaml:"genesisTimestampSec"`
+	SporkAddress        string                        `yaml:"sporkAddress"`
+	Znn                 genesisTokenTemplate          `yaml:"znn"`
+	Qsr                 genesisTokenTemplate          `yaml:"qsr"`
+	Pillars             []genesisPillarTemplate       `yaml:"pillars"`
+	Delegations         []genesisDelegationTemplate   `yaml:"delegations"`
+	Fusions             []genesisFusionTemplate       `yaml:"fusions"`
+	Balances            []genesisBalanceTemplate      `yaml:"balances"`
+	LegacyPillars       []genesisLegacyPillarTemplate `yaml:"legacyPillars"`
+	SentinelFunds       []string                      `yaml:"sentinelFunds"`
+	Sporks              []genesisSporkTemplate        `yaml:"sporks"`
+	Swap                []genesisSwapTemplate         `yaml:"swap"`
+	Accelerator         genesisFundsTemplate          `yaml:"accelerator"`
+}
+
+type genesisTokenTemplate struct {
+	Name      string `yaml:"name"`
+	Symbol    string `yaml:"symbol"`
+	Domain    string `yaml:"domain"`
+	MaxSupply string `yaml:"maxSupply"`
+}
+
+// genesisPillarTemplate is a pillar in addition to the ones of the
+// generated nodes. Stake and reward default to the producer address.
+type genesisPillarTemplate struct {
+	Name     string `yaml:"name"`
+	Producer string `yaml:"producer"`
+	Stake    string `yaml:"stake"`
+	Reward   string `yaml:"reward"`
+	Znn      string `yaml:"znn"`
+}
+
+type genesisDelegationTemplate struct {
+	Address string `yaml:"address"`
+	Pillar  string `yaml:"pillar"`
+}
+
+// genesisLegacyPillarTemplate lets the owner of a legacy key register up to
+// pillarCount legacy pillars.
+type genesisLegacyPillarTemplate struct {
+	KeyIdHash   string `yaml:"keyIdHash"`
+	PillarCount uint8  `yaml:"pillarCount"`
+}
+
+type genesisFusionTemplate struct {
+	Address string `yaml:"address"`
+	Qsr     string `yaml:"qsr"`
+}
+
+type genesisBalanceTemplate struct {
+	Address string `yaml:"address"`
+	Znn     string `yaml:"znn"`
+	Qsr     string `yaml:"qsr"`
+}
+
+// genesisSporkTemplate overrides an implemented spork or adds a custom one,
+// which must be named.
+type genesisSporkTemplate struct {
+	Id                string `yaml:"id"`
+	Activated         bool   `yaml:"activated"`
+	EnforcementHeight uint64 `yaml:"enforcementHeight"`
+	Name              string `yaml:"name"`
+	Description       string `yaml:"description"`
+}
+
+type genesisSwapTemplate struct {
+	KeyIdHash string `yaml:"keyIdHash"`
+	Znn       string `yaml:"znn"`
+	Qsr       string `yaml:"qsr"`
+}
+
+type genesisFundsTemplate struct {
+	Znn string `yaml:"znn"`
+	Qsr string `yaml:"qsr"`
+}
+
+func defaultGenesisTemplate() *genesisTemplate {
+	return &genesisTemplate{
+		ChainId:   321,
+		ExtraData: "/thank_you_bich_dao",
+		Znn: genesisTokenTemplate{
+			Name:      "tZNN",
+			Symbol:    "tZNN",
+			Domain:    "biginches.club",
+			MaxSupply: "90071992.54740991",
+		},
+		Qsr: genesisTokenTemplate{
+			Name:      "tQSR",
+			Symbol:    "tQSR",
+			Domain:    "biginches.club",
+			MaxSupply: "90071992.54740991",
+		},
+		Accelerator: genesisFundsTemplate{
+			Znn: "772135.999888",
+			Qsr: "7721359.99888",
+		},
+	}
+}
+
+// readGenesisTemplate applies the YAML file at path on top of the defaults.
+func readGenesisTemplate(path string) (*genesisTemplate, error) {
+	t := defaultGenesisTemplate()
+	if path == "" {
+		return t, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(t); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid genesis-template %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// mergeGenesisFlags applies the genesis flags to t. Flag entries are added
+// to the ones of the template, single values replace them.
+func mergeGenesisFlags(ctx *cli.Context, t *genesisTemplate) error {
+	if ctx.IsSet(ChainIdFlag.Name) {
+		t.ChainId = ctx.Uint64(ChainIdFlag.Name)
+	}
+	if ctx.IsSet(ExtraDataFlag.Name) {
+		t.ExtraData = ctx.String(ExtraDataFlag.Name)
+	}
+	if ctx.IsSet(GenesisTimestampFlag.Name) {
+		timestamp, err := parseGenesisTimestamp(ctx.String(GenesisTimestampFlag.Name))
+		if err != nil {
+			return err
+		}
+		t.GenesisTimestampSec = timestamp
+	}
+	mergeTokenFlags(ctx, &t.Znn, "znn")
+	mergeTokenFlags(ctx, &t.Qsr, "qsr")
+	if ctx.IsSet(SporkAddressFlag.Name) {
+		t.SporkAddress = ctx.String(SporkAddressFlag.Name)
+	}
+
+	for _, s := range ctx.StringSlice(GenesisBlockFlag.Name) {
+		ss := strings.Split(s, "/")
+		if len(ss) != 3 {
+			return errors.New("genesis-block flags must be in the format --genesis-block=<address>/<znnAmount>/<qsrAmount>")
+		}
+		t.Balances = append(t.Balances, genesisBalanceTemplate{Address: ss[0], Znn: ss[1], Qsr: ss[2]})
+	}
+
+	for _, s := range ctx.StringSlice(GenesisFusionFlag.Name) {
+		ss := strings.Split(s, "/")
+		if len(ss) != 2 {
+			return errors.New("genesis-fusion flags must be in the format --genesis-fusion=<address>/<qsrAmount>")
+		}
+		t.Fusions = append(t.Fusions, genesisFusionTemplate{Address: ss[0], Qsr: ss[1]})
+	}
+
+	for _, s := range ctx.StringSlice(GenesisPillarFlag.Name) {
+		ss := strings.Split(s, "/")
+		if len(ss) != 5 {
+			return errors.New("genesis-pillar flags must be in the format --genesis-pillar=<name>/<producerAddress>/<stakeAddress>/<rewardAddress>/<znnAmount>")
+		}
+		t.Pillars = append(t.Pillars, genesisPillarTemplate{Name: ss[0], Producer: ss[1], Stake: ss[2], Reward: ss[3], Znn: ss[4]})
+	}
+
+	for _, s := range ctx.StringSlice(GenesisDelegationFlag.Name) {
+		ss := strings.Split(s, "/")
+		if len(ss) != 2 {
+			return errors.New("genesis-delegation flags must be in the format --genesis-delegation=<address>/<pillarName>")
+		}
+		t.Delegations = append(t.Delegations, genesisDelegationTemplate{Address: ss[0], Pillar: ss[1]})
+	}
+
+	for _, s := range ctx.StringSlice(GenesisLegacyPillarFlag.Name) {
+		ss := strings.Split(s, "/")
+		if len(ss) != 2 {
+			return errors.New("genesis-legacy-pillar flags must be in the format --genesis-legacy-pillar=<keyIdHash>/<pillarCount>")
+		}
+		count, err := strconv.ParseUint(ss[1], 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid genesis-legacy-pillar pillar count %q", ss[1])
+		}
+		t.LegacyPillars = append(t.LegacyPillars, genesisLegacyPillarTemplate{KeyIdHash: ss[0], PillarCount: uint8(count)})
+	}
+
+	t.SentinelFunds = append(t.SentinelFunds, ctx.StringSlice(GenesisSentinelFundsFlag.Name)...)
+
+	for _, s := range ctx.StringSlice(GenesisSwapFlag.Name) {
+		ss := strings.Split(s, "/")
+		if len(ss) != 3 {
+			return errors.New("genesis-swap flags must be in the format --genesis-swap=<keyIdHash>/<znnAmount>/<qsrAmount>")
+		}
+		t.Swap = append(t.Swap, genesisSwapTemplate{KeyIdHash: ss[0], Znn: ss[1], Qsr: ss[2]})
+	}
+
+	for _, s := range *ctx.Generic(GenesisSporkFlag.Name).(*rawStringSlice) {
+		spork, err := parseGenesisSpork(s)
+		if err != nil {
+			return err
+		}
+		t.Sporks = append(t.Sporks, spork)
+	}
+	return nil
+}
+
+func mergeTokenFlags(ctx *cli.Context, tt *genesisTokenTemplate, token string) {
+	for field, value := range map[string]*string{
+		"name":       &tt.Name,
+		"symbol":     &tt.Symbol,
+		"domain":     &tt.Domain,
+		"max-supply": &tt.MaxSupply,
+	} {
+		if name := token + "-" + field; ctx.IsSet(name) {
+			*value = ctx.String(name)
+		}
+	}
+}
+
+// parseGenesisTimestamp accepts unix seconds or an RFC 3339 time.
+func parseGenesisTimestamp(s string) (int64, error) {
+	if timestamp, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if timestamp <= 0 {
+			return 0, errors.New("genesis-timestamp must be after 1970")
+		}
+		return timestamp, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid genesis-timestamp %q, expected unix seconds or RFC 3339", s)
+	}
+	if t.Unix() <= 0 {
+		return 0, errors.New("genesis-timestamp must be after 1970")
+	}
+	return t.Unix(), nil
+}
+
+// rawStringSlice collects every value of a repeated flag as given.
+type rawStringSlice []string
+
+func (v *rawStringSlice) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+func (v *rawStringSlice) String() string {
+	return strings.Join(*v, " ")
+}
+
+// parseGenesisSpork parses a --genesis-spork entry.
+func parseGenesisSpork(s string) (genesisSporkTemplate, error) {
+	ss := strings.SplitN(s, ",", 5)
+	if len(ss) < 2 {
+		return genesisSporkTemplate{}, errors.New("genesis-spork flags must be in the format --genesis-spork=<hashId>,<true|false>[,<enforcementHeight>[,<name>[,<description>]]]")
+	}
+
+	spork := genesisSporkTemplate{Id: ss[0]}
+	activated, err := strconv.ParseBool(ss[1])
+	if err != nil {
+		return spork, fmt.Errorf("invalid genesis-spork activation status %q, expected true or false", ss[1])
+	}
+	spork.Activated = activated
+	if len(ss) > 2 && ss[2] != "" {
+		height, err := strconv.ParseUint(ss[2], 10, 64)
+		if err != nil {
+			return spork, fmt.Errorf("invalid genesis-spork enforcement height %q", ss[2])
+		}
+		spork.EnforcementHeight = height
+	}
+	if len(ss) > 3 {
+		spork.Name = ss[3]
+	}
+	if len(ss) > 4 {
+		spork.Description = ss[4]
+	}
+	return spork, nil
+}
+
+// spork validates the entry. Sporks implemented by go-zenon default to the
+// "genesis-spork" name, custom ids must be named.
+func (st genesisSporkTemplate) spork() (*definition.Spork, error) {
+	id, err := types.HexToHash(st.Id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis spork id %q: %w", st.Id, err)
+	}
+	if !st.Activated && st.EnforcementHeight != 0 {
+		return nil, errors.New("genesis spork enforcement height can only be set for activated sporks")
+	}
+	if _, ok := types.ImplementedSporksMap[id]; !ok && st.Name == "" {
+		return nil, fmt.Errorf("unknown genesis spork id %v, give it a name to add a custom spork", id)
+	}
+
+	spork := &definition.Spork{
+		Id:                id,
+		Name:              "genesis-spork",
+		Description:       "genesis-spork",
+		Activated:         st.Activated,
+		EnforcementHeight: st.EnforcementHeight,
+	}
+	if st.Name != "" {
+		if len(st.Name) < constants.SporkNameMinLength || len(st.Name) > constants.SporkNameMaxLength {
+			return nil, fmt.Errorf("genesis spork name must be %d to %d characters in length", constants.SporkNameMinLength, constants.SporkNameMaxLength)
+		}
+		spork.Name = st.Name
+		spork.Description = st.Name
+	}
+	if st.Description != "" {
+		if len(st.Description) > constants.SporkDescriptionMaxLength {
+			return nil, fmt.Errorf("genesis spork description cannot exceed %d characters in length", constants.SporkDescriptionMaxLength)
+		}
+		spork.Description = st.Description
+	}
+	return spork, nil
+}
+
+// parseGenesisUserAddress parses an address of a genesis entry, which cannot
+// be an embedded contract.
+func parseGenesisUserAddress(entry string, s string) (types.Address, error) {
+	a, err := types.ParseAddress(s)
+	if err != nil {
+		return a, fmt.Errorf("invalid %s address %q: %w", entry, s, err)
+	}
+	if types.IsEmbeddedAddress(a) {
+		return a, fmt.Errorf("%s can only be set for user addresses", entry)
+	}
+	return a, nil
+}
+
+func parseGenesisAmount(s string, decimals uint8) (*big.Int, error) {
+	if s == "" {
+		return big.NewInt(0), nil
+	}
+	return parseAmount(s, decimals)
+}
@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/ignition-pillar/go-zdk/client"
 	"github.com/ignition-pillar/go-zdk/zdk"
@@ -19,12 +20,18 @@ import (
 var url string
 var chainId int
 var walletDir string
+var nomctlDir string
 
 const ZnnDecimals = 8
 const QsrDecimals = 8
 
 const rpcMaxPageSize = 1024
 
+// fuseMinAmount is the smallest amount of QSR the plasma contract accepts.
+var fuseMinAmount = big.NewInt(10 * 100000000)
+
+const momentumPollInterval = 2 * time.Second
+
 func connect(url string, chainId int) (*zdk.Zdk, error) {
 	rpc, err := client.NewClient(url, client.ChainIdentifier(uint64(chainId)))
 	if err != nil {
@@ -34,6 +41,24 @@ func connect(url string, chainId int) (*zdk.Zdk, error) {
 	return z, nil
 }
 
+var embeddedContractNames = map[types.Address]string{
+	types.PlasmaContract:      "plasma",
+	types.PillarContract:      "pillar",
+	types.TokenContract:       "token",
+	types.SentinelContract:    "sentinel",
+	types.SwapContract:        "swap",
+	types.StakeContract:       "stake",
+	types.SporkContract:       "spork",
+	types.AcceleratorContract: "accelerator",
+	types.LiquidityContract:   "liquidity",
+	types.BridgeContract:      "bridge",
+}
+
+func embeddedContractName(address types.Address) (string, bool) {
+	name, ok := embeddedContractNames[address]
+	return name, ok
+}
+
 func formatAmount(amount *big.Int, decimals uint8) string {
 	return decimal.NewFromBigInt(amount, int32(decimals)*-1).String()
 }
@@ -44,7 +69,7 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	nomctlDir := filepath.Join(homeDir, ".nomctl")
+	nomctlDir = filepath.Join(homeDir, ".nomctl")
 	mode := int(0700)
 	err = os.MkdirAll(nomctlDir, os.FileMode(mode))
 	if err != nil {
@@ -56,17 +81,23 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if err := newApp().Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func newApp() *cli.App {
 	utilsValidateAddress := &cli.Command{
 		Name:  "validate-address",
 		Usage: "",
 		Action: func(cCtx *cli.Context) error {
 			if cCtx.NArg() != 1 {
 				fmt.Println("Incorrect number of arguments. Expected:")
-				fmt.Println("validate-address address")
+				fmt.Println("validate-address address|@contact")
 				return nil
 			}
 			a := cCtx.Args().Get(0)
-			address, err := types.ParseAddress(a)
+			address, err := resolveAddress(a)
 			if err != nil {
 				return err
 			}
@@ -77,9 +108,23 @@ func main() {
 
 	utilsSubcommands := []*cli.Command{
 		utilsValidateAddress,
+		utilsAddressInfo,
+		utilsDeriveAddress,
+		utilsPubKeyToAddress,
+		utilsSignMessage,
+		utilsVerifyMessage,
+		utilsConvert,
+		utilsHash,
+		utilsZtsInfo,
+		utilsAddressBytes,
+		utilsBytesToAddress,
+		utilsHex,
+		utilsBase64,
+		utilsSwapKeyId,
+		utilsPoWBenchmark,
 	}
 
-	app := &cli.App{
+	return &cli.App{
 		Name:  "nomctl",
 		Usage: "A community controller for the Network of Momentum",
 		Commands: []*cli.Command{
@@ -90,10 +135,8 @@ func main() {
 				Subcommands: utilsSubcommands,
 			},
 			&devnetCommand,
+			&devnetToolsCommand,
+			&signerCommand,
 		},
 	}
-
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
-	}
 }
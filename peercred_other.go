@@ -0,0 +1,14 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"net"
+)
+
+// peerUid is only implemented on linux, elsewhere signer policies cannot
+// tell clients apart.
+func peerUid(conn net.Conn) (int, error) {
+	return -1, errors.New("unix socket peer credentials are not supported on this platform")
+}
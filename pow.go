@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+	"golang.org/x/crypto/sha3"
+)
+
+const powProgressInterval = time.Second
+
+// powDifficultyPerPlasma is the PoW difficulty worth one unit of plasma.
+const powDifficultyPerPlasma = 1500
+
+// Difficulties required without any fused plasma for a plain send and for
+// a call to an embedded contract.
+const (
+	powSendDifficulty     = 21000 * powDifficultyPerPlasma
+	powEmbeddedDifficulty = 105000 * powDifficultyPerPlasma
+)
+
+var big64 = new(big.Int).Lsh(big.NewInt(1), 64)
+
+// powTarget returns the little endian threshold the first 8 bytes of a
+// nonce hash must reach for the given difficulty, matching go-zenon.
+func powTarget(difficulty uint64) [8]byte {
+	if difficulty == 0 {
+		return [8]byte{}
+	}
+	x := new(big.Int).Div(big64, new(big.Int).SetUint64(difficulty))
+	x.Sub(big64, x)
+	var target [8]byte
+	binary.LittleEndian.PutUint64(target[:], x.Uint64())
+	return target
+}
+
+func powMeetsTarget(h []byte, target [8]byte) bool {
+	for i := 7; i >= 0; i-- {
+		if h[i] > target[i] {
+			return true
+		}
+		if h[i] < target[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// powDataHash is the hash a block's PoW nonce is computed over.
+func powDataHash(address types.Address, previousHash types.Hash) types.Hash {
+	return types.NewHash(append(address.Bytes(), previousHash.Bytes()...))
+}
+
+func powThreads(threads int) int {
+	if threads < 1 {
+		return runtime.NumCPU()
+	}
+	return threads
+}
+
+// powDuration is the expected time to compute hashes at rate hashes/sec.
+func powDuration(hashes uint64, rate float64) time.Duration {
+	return time.Duration(float64(hashes) / rate * float64(time.Second))
+}
+
+// runPoW searches for a nonce on threads goroutines, each starting from a
+// random nonce, until one is found or ctx is done. Every hash computed is
+// added to hashes.
+func runPoW(ctx context.Context, dataHash types.Hash, difficulty uint64, threads int, hashes *atomic.Uint64) ([8]byte, error) {
+	target := powTarget(difficulty)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	found := make(chan [8]byte, 1)
+	errs := make(chan error, 1)
+	for i := 0; i < powThreads(threads); i++ {
+		go func() {
+			var data [8 + types.HashSize]byte
+			if _, err := rand.Read(data[:8]); err != nil {
+				select {
+				case errs <- err:
+				default:
+				}
+				return
+			}
+			copy(data[8:], dataHash.Bytes())
+			for {
+				for n := 0; n < 1024; n++ {
+					h := sha3.Sum256(data[:])
+					if powMeetsTarget(h[:8], target) {
+						var nonce [8]byte
+						copy(nonce[:], data[:8])
+						hashes.Add(uint64(n + 1))
+						select {
+						case found <- nonce:
+						default:
+						}
+						return
+					}
+					binary.LittleEndian.PutUint64(data[:8], binary.LittleEndian.Uint64(data[:8])+1)
+				}
+				hashes.Add(1024)
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+			}
+		}()
+	}
+
+	select {
+	case nonce := <-found:
+		return nonce, nil
+	case err := <-errs:
+		return [8]byte{}, err
+	case <-ctx.Done():
+		return [8]byte{}, ctx.Err()
+	}
+}
+
+// generatePoW runs runPoW while printing the hash rate and an ETA based
+// on the expected number of hashes for difficulty.
+func generatePoW(ctx context.Context, dataHash types.Hash, difficulty uint64, threads int) ([8]byte, error) {
+	var hashes atomic.Uint64
+	start := time.Now()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(powProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				count := hashes.Load()
+				rate := float64(count) / time.Since(start).Seconds()
+				eta := "any moment"
+				if count < difficulty && rate > 0 {
+					eta = powDuration(difficulty-count, rate).Round(time.Second).String()
+				}
+				fmt.Printf("\rGenerating PoW: %d hashes, %.0f H/s, ETA %s   ", count, rate, eta)
+			}
+		}
+	}()
+
+	nonce, err := runPoW(ctx, dataHash, difficulty, threads, &hashes)
+	close(done)
+	<-stopped
+	if time.Since(start) >= powProgressInterval {
+		fmt.Println()
+	}
+	if err != nil {
+		return nonce, err
+	}
+	fmt.Println("PoW generated in", time.Since(start).Round(time.Millisecond), "after", hashes.Load(), "hashes")
+	return nonce, nil
+}
+
+var utilsPoWBenchmark = &cli.Command{
+	Name:  "pow-benchmark",
+	Usage: "[seconds]",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "threads",
+			Usage: "Number of threads to use, defaults to all CPU cores",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() > 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("pow-benchmark [seconds]")
+			return nil
+		}
+		seconds := 10
+		if cCtx.NArg() == 1 {
+			var err error
+			seconds, err = strconv.Atoi(cCtx.Args().Get(0))
+			if err != nil || seconds <= 0 {
+				fmt.Println("Error! seconds must be a positive integer")
+				return err
+			}
+		}
+		threads := powThreads(cCtx.Int("threads"))
+		fmt.Println("Benchmarking PoW on", threads, "thread(s) for", seconds, "second(s) ...")
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(seconds)*time.Second)
+		defer cancel()
+		var hashes atomic.Uint64
+		start := time.Now()
+		// a maximum difficulty target is practically never reached
+		_, _ = runPoW(ctx, types.ZeroHash, ^uint64(0), threads, &hashes)
+		elapsed := time.Since(start)
+
+		rate := float64(hashes.Load()) / elapsed.Seconds()
+		fmt.Printf("%d hashes in %v\n", hashes.Load(), elapsed.Round(time.Millisecond))
+		fmt.Printf("%.0f H/s (%.0f H/s per thread)\n", rate, rate/float64(threads))
+		fmt.Printf("Expected time for a send (difficulty %d): %v\n", powSendDifficulty, powDuration(powSendDifficulty, rate).Round(time.Millisecond))
+		fmt.Printf("Expected time for an embedded call (difficulty %d): %v\n", powEmbeddedDifficulty, powDuration(powEmbeddedDifficulty, rate).Round(time.Millisecond))
+		return nil
+	},
+}
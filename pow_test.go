@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zenon-network/go-zenon/chain/nom"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/pow"
+	"github.com/zenon-network/go-zenon/rpc/api/embedded"
+)
+
+func TestRunPoWPassesGoZenonCheck(t *testing.T) {
+	publicKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+	for _, difficulty := range []uint64{1, 1500, 100000} {
+		block := &nom.AccountBlock{
+			Address:      types.PubKeyToAddress(publicKey),
+			PreviousHash: types.NewHash([]byte("previous")),
+			Difficulty:   difficulty,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		var hashes atomic.Uint64
+		nonce, err := runPoW(ctx, powDataHash(block.Address, block.PreviousHash), difficulty, 2, &hashes)
+		cancel()
+		if err != nil {
+			t.Fatalf("difficulty %d: %v", difficulty, err)
+		}
+		block.Nonce.Data = nonce
+		if !pow.CheckPoWNonce(block) {
+			t.Errorf("difficulty %d: nonce %x is rejected by go-zenon", difficulty, nonce)
+		}
+	}
+}
+
+func TestRunPoWStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var hashes atomic.Uint64
+	if _, err := runPoW(ctx, types.ZeroHash, ^uint64(0), 1, &hashes); err == nil {
+		t.Fatal("expected an error once the context is done")
+	}
+	if hashes.Load() == 0 {
+		t.Error("no hashes were counted")
+	}
+}
+
+func TestSelectPlasma(t *testing.T) {
+	enough := embedded.GetRequiredResult{AvailablePlasma: 50000, BasePlasma: 21000, RequiredDifficulty: 0}
+	insufficient := embedded.GetRequiredResult{AvailablePlasma: 1000, BasePlasma: 21000, RequiredDifficulty: 30000000}
+
+	tests := []struct {
+		name       string
+		mode       string
+		required   embedded.GetRequiredResult
+		fused      uint64
+		difficulty uint64
+		wantErr    bool
+	}{
+		{name: "auto with enough plasma", mode: powAuto, required: enough, fused: 21000},
+		{name: "never with enough plasma", mode: powNever, required: enough, fused: 21000},
+		{name: "always with enough plasma", mode: powAlways, required: enough, difficulty: 21000 * powDifficultyPerPlasma},
+		{name: "auto with insufficient plasma", mode: powAuto, required: insufficient, fused: 1000, difficulty: 30000000},
+		{name: "always with insufficient plasma", mode: powAlways, required: insufficient, difficulty: 21000 * powDifficultyPerPlasma},
+		{name: "never with insufficient plasma", mode: powNever, required: insufficient, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// stale values must always be replaced
+			block := &nom.AccountBlock{FusedPlasma: 7, Difficulty: 7}
+			required := tt.required
+			err := selectPlasma(tt.mode, &required, block)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if block.FusedPlasma != tt.fused || block.Difficulty != tt.difficulty {
+				t.Errorf("got fused plasma %d and difficulty %d, want %d and %d", block.FusedPlasma, block.Difficulty, tt.fused, tt.difficulty)
+			}
+		})
+	}
+}
@@ -0,0 +1,556 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/nom"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// A proposal is a transaction prepared by one operator that can only be
+// published by the holder of the sending key once enough members of the
+// signer set fixed at creation have approved it. NoM has no native
+// multisig, so the approvals are detached signatures checked locally by
+// nomctl. Proposal files are untrusted: every command checks them against
+// the operator's own signer set file given with --signers.
+
+const proposalDomain = "nomctl proposal v1\n"
+
+type proposalTransaction struct {
+	ChainIdentifier uint64 `json:"chainIdentifier"`
+	From            string `json:"from"`
+	ToAddress       string `json:"toAddress"`
+	TokenStandard   string `json:"tokenStandard"`
+	Amount          string `json:"amount"`
+	Data            string `json:"data"`
+	Description     string `json:"description"`
+	Nonce           string `json:"nonce"`
+	// Threshold of approvals required from Signers
+	Threshold int      `json:"threshold"`
+	Signers   []string `json:"signers"`
+}
+
+type proposalApproval struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+type proposal struct {
+	Transaction proposalTransaction `json:"transaction"`
+	Approvals   []proposalApproval  `json:"approvals"`
+	Executed    string              `json:"executed,omitempty"`
+}
+
+// signerSet is the M-of-N approval policy a proposal is created with.
+type signerSet struct {
+	Threshold int      `json:"threshold"`
+	Signers   []string `json:"signers"`
+}
+
+func (set *signerSet) validate() error {
+	unique := make(map[string]bool)
+	for _, s := range set.Signers {
+		if _, err := types.ParseAddress(s); err != nil {
+			return fmt.Errorf("invalid signer %s: %w", s, err)
+		}
+		unique[s] = true
+	}
+	if set.Threshold < 1 || set.Threshold > len(unique) {
+		return fmt.Errorf("threshold must be between 1 and the number of signers (%d)", len(unique))
+	}
+	return nil
+}
+
+func (set *signerSet) members() map[string]bool {
+	members := make(map[string]bool)
+	for _, s := range set.Signers {
+		members[s] = true
+	}
+	return members
+}
+
+// checkSignerSet refuses a proposal that does not embed exactly the
+// operator's signer set, since anyone can write a proposal file with a
+// threshold and signers of their own.
+func (p *proposal) checkSignerSet(set *signerSet) error {
+	if p.Transaction.Threshold != set.Threshold {
+		return fmt.Errorf("the proposal requires %d approval(s) but the signer set requires %d", p.Transaction.Threshold, set.Threshold)
+	}
+	embedded := (&signerSet{Signers: p.Transaction.Signers}).members()
+	members := set.members()
+	if len(embedded) != len(members) {
+		return errors.New("the signers of the proposal differ from the signer set")
+	}
+	for s := range embedded {
+		if !members[s] {
+			return fmt.Errorf("%s signs the proposal but is not in the signer set", s)
+		}
+	}
+	return nil
+}
+
+// digest is the hash approvers sign. It covers every field of the
+// transaction, including the signer set and a random nonce so approvals
+// cannot be replayed onto an otherwise identical proposal.
+func (p *proposal) digest() (types.Hash, error) {
+	data, err := json.Marshal(p.Transaction)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return types.NewHash(append([]byte(proposalDomain), data...)), nil
+}
+
+// template converts the proposed transaction into a send block template.
+func (p *proposal) template() (*nom.AccountBlock, error) {
+	toAddress, err := types.ParseAddress(p.Transaction.ToAddress)
+	if err != nil {
+		return nil, err
+	}
+	zts, err := types.ParseZTS(p.Transaction.TokenStandard)
+	if err != nil {
+		return nil, err
+	}
+	amount, ok := new(big.Int).SetString(p.Transaction.Amount, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", p.Transaction.Amount)
+	}
+	data, err := hex.DecodeString(p.Transaction.Data)
+	if err != nil {
+		return nil, err
+	}
+	template := sendTemplate(toAddress, zts, amount)
+	template.Data = data
+	return template, nil
+}
+
+// verifyApproval checks that a is a valid signature of digest by the
+// address it claims to be from.
+func verifyApproval(a proposalApproval, digest types.Hash) (types.Address, error) {
+	publicKey, err := hex.DecodeString(a.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return types.Address{}, fmt.Errorf("invalid public key for %s", a.Address)
+	}
+	signature, err := hex.DecodeString(a.Signature)
+	if err != nil {
+		return types.Address{}, fmt.Errorf("invalid signature for %s", a.Address)
+	}
+	address := types.PubKeyToAddress(publicKey)
+	if address.String() != a.Address {
+		return types.Address{}, fmt.Errorf("public key of %s does not match its address", a.Address)
+	}
+	if !ed25519.Verify(publicKey, digest.Bytes(), signature) {
+		return types.Address{}, fmt.Errorf("signature of %s does not match the proposal", a.Address)
+	}
+	return address, nil
+}
+
+// countApprovals returns the number of distinct members of set with a
+// valid approval, printing why any other approval is ignored.
+func countApprovals(p *proposal, set *signerSet, digest types.Hash) int {
+	members := set.members()
+	approved := make(map[types.Address]bool)
+	for _, a := range p.Approvals {
+		address, err := verifyApproval(a, digest)
+		if err != nil {
+			fmt.Println("Ignoring approval:", err)
+			continue
+		}
+		if !members[address.String()] {
+			fmt.Println("Ignoring approval from", address, "which is not in the signer set")
+			continue
+		}
+		approved[address] = true
+	}
+	return len(approved)
+}
+
+func readProposal(path string) (*proposal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &proposal{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func writeProposal(path string, p *proposal) error {
+	data, err := json.MarshalIndent(p, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func readSignerSet(path string) (*signerSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := &signerSet{}
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, err
+	}
+	if err := set.validate(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func printProposal(p *proposal) {
+	t := p.Transaction
+	fmt.Println("Proposal")
+	fmt.Println("  Chain identifier:", t.ChainIdentifier)
+	fmt.Println("  From:", t.From)
+	fmt.Println("  To:", t.ToAddress)
+	fmt.Println("  Token:", t.TokenStandard)
+	fmt.Println("  Amount (base units):", t.Amount)
+	if t.Data != "" {
+		fmt.Println("  Data:", t.Data)
+	}
+	if t.Description != "" {
+		fmt.Println("  Description:", t.Description)
+	}
+	fmt.Println("  Required approvals:", t.Threshold, "of", len(t.Signers))
+	for _, s := range t.Signers {
+		fmt.Println("   ", s)
+	}
+	fmt.Println("  Approvals:", len(p.Approvals))
+	for _, a := range p.Approvals {
+		fmt.Println("   ", a.Address)
+	}
+	if p.Executed != "" {
+		fmt.Println("  Executed in block:", p.Executed)
+	}
+}
+
+func executedProposalPath(digest types.Hash) string {
+	return filepath.Join(nomctlDir, "proposals", digest.String())
+}
+
+var proposalSignersFlag = &cli.StringFlag{
+	Name:     "signers",
+	Usage:    "Signer set file with the approval threshold and signer addresses proposals are checked against",
+	Required: true,
+}
+
+// readProposalSignerSet reads the operator's signer set and checks that p
+// was created for it.
+func readProposalSignerSet(cCtx *cli.Context, p *proposal) (*signerSet, error) {
+	set, err := readSignerSet(cCtx.String(proposalSignersFlag.Name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer set: %w", err)
+	}
+	if err := p.checkSignerSet(set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+var znnCliProposalCreate = &cli.Command{
+	Name:  "proposal.create",
+	Usage: "file fromAddress|@contact toAddress|@contact amount [ZNN|QSR|ZTS]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "description",
+			Usage: "Description shown to approvers",
+		},
+		proposalSignersFlag,
+	},
+	Action: func(cCtx *cli.Context) error {
+		if !(cCtx.NArg() == 4 || cCtx.NArg() == 5) {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("proposal.create file fromAddress|@contact toAddress|@contact amount [ZNN|QSR|ZTS]")
+			return nil
+		}
+		path := cCtx.Args().Get(0)
+		if _, err := os.Stat(path); err == nil {
+			fmt.Println("Error!", path, "already exists")
+			return nil
+		}
+		from, err := resolveAddress(cCtx.Args().Get(1))
+		if err != nil {
+			fmt.Println("Error! Invalid from address:", err)
+			return err
+		}
+		toAddress, err := resolveAddress(cCtx.Args().Get(2))
+		if err != nil {
+			fmt.Println("Error! Invalid to address:", err)
+			return err
+		}
+		set, err := readSignerSet(cCtx.String(proposalSignersFlag.Name))
+		if err != nil {
+			fmt.Println("Error reading signer set:", err)
+			return err
+		}
+		zts := types.ZnnTokenStandard
+		if cCtx.NArg() == 5 {
+			zts, err = parseTokenStandard(cCtx.Args().Get(4))
+			if err != nil {
+				fmt.Println("Error! Invalid token standard:", err)
+				return err
+			}
+		}
+
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+		info, err := z.Ledger.GetAccountInfoByAddress(from)
+		if err != nil {
+			fmt.Println("Error getting account info:", err)
+			return err
+		}
+		balance, ok := info.BalanceInfoMap[zts]
+		if !ok {
+			fmt.Println("Error!", from, "does not hold any", zts.String(), "tokens")
+			return nil
+		}
+		amount, err := parseAmount(cCtx.Args().Get(3), balance.TokenInfo.Decimals)
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+		if amount.Sign() <= 0 {
+			fmt.Println("Error! Amount must be greater than 0")
+			return nil
+		}
+
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		p := &proposal{
+			Transaction: proposalTransaction{
+				ChainIdentifier: uint64(chainId),
+				From:            from.String(),
+				ToAddress:       toAddress.String(),
+				TokenStandard:   zts.String(),
+				Amount:          amount.String(),
+				Description:     cCtx.String("description"),
+				Nonce:           hex.EncodeToString(nonce),
+				Threshold:       set.Threshold,
+				Signers:         set.Signers,
+			},
+			Approvals: []proposalApproval{},
+		}
+		if err := writeProposal(path, p); err != nil {
+			fmt.Println("Error writing proposal:", err)
+			return err
+		}
+		fmt.Println("Proposal to send", formatAmount(amount, balance.TokenInfo.Decimals), balance.TokenInfo.TokenSymbol, "from", from, "to", toAddress, "written to", path)
+		return nil
+	},
+}
+
+var znnCliProposalShow = &cli.Command{
+	Name:  "proposal.show",
+	Usage: "file",
+	Flags: []cli.Flag{proposalSignersFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("proposal.show file")
+			return nil
+		}
+		p, err := readProposal(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error reading proposal:", err)
+			return err
+		}
+		digest, err := p.digest()
+		if err != nil {
+			return err
+		}
+		printProposal(p)
+		fmt.Println("  Digest:", digest)
+
+		set, err := readProposalSignerSet(cCtx, p)
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+		fmt.Println(countApprovals(p, set, digest), "of", set.Threshold, "required approval(s)")
+		return nil
+	},
+}
+
+var znnCliProposalApprove = &cli.Command{
+	Name:  "proposal.approve",
+	Usage: "file",
+	Flags: []cli.Flag{proposalSignersFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("proposal.approve file")
+			return nil
+		}
+		path := cCtx.Args().Get(0)
+		p, err := readProposal(path)
+		if err != nil {
+			fmt.Println("Error reading proposal:", err)
+			return err
+		}
+		if p.Executed != "" {
+			fmt.Println("Error! The proposal has already been executed in block", p.Executed)
+			return nil
+		}
+		if _, err := p.template(); err != nil {
+			fmt.Println("Error! Invalid proposal:", err)
+			return err
+		}
+		set, err := readProposalSignerSet(cCtx, p)
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+		digest, err := p.digest()
+		if err != nil {
+			return err
+		}
+		printProposal(p)
+
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+		if !set.members()[kp.Address().String()] {
+			fmt.Println("Error!", kp.Address(), "is not in the signer set")
+			return nil
+		}
+		signature, err := kp.Sign(digest.Bytes())
+		if err != nil {
+			fmt.Println("Error signing proposal:", err)
+			return err
+		}
+
+		approval := proposalApproval{
+			Address:   kp.Address().String(),
+			PublicKey: hex.EncodeToString(kp.PublicKey()),
+			Signature: hex.EncodeToString(signature),
+		}
+		approvals := []proposalApproval{approval}
+		for _, a := range p.Approvals {
+			if a.Address != approval.Address {
+				approvals = append(approvals, a)
+			}
+		}
+		p.Approvals = approvals
+		if err := writeProposal(path, p); err != nil {
+			fmt.Println("Error writing proposal:", err)
+			return err
+		}
+		fmt.Println("Proposal approved by", kp.Address())
+		return nil
+	},
+}
+
+var znnCliProposalExecute = &cli.Command{
+	Name:  "proposal.execute",
+	Usage: "file",
+	Flags: []cli.Flag{proposalSignersFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("proposal.execute file")
+			return nil
+		}
+		path := cCtx.Args().Get(0)
+		p, err := readProposal(path)
+		if err != nil {
+			fmt.Println("Error reading proposal:", err)
+			return err
+		}
+		if p.Transaction.ChainIdentifier != uint64(chainId) {
+			fmt.Println("Error! The proposal is for chain identifier", p.Transaction.ChainIdentifier)
+			return nil
+		}
+		template, err := p.template()
+		if err != nil {
+			fmt.Println("Error! Invalid proposal:", err)
+			return err
+		}
+		digest, err := p.digest()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(executedProposalPath(digest)); err == nil || p.Executed != "" {
+			fmt.Println("Error! The proposal has already been executed")
+			return nil
+		}
+
+		set, err := readProposalSignerSet(cCtx, p)
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+		approved := countApprovals(p, set, digest)
+		if approved < set.Threshold {
+			fmt.Println("Error! The proposal has", approved, "of", set.Threshold, "required approval(s)")
+			return errors.New("not enough approvals")
+		}
+		printProposal(p)
+
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+		if kp.Address().String() != p.Transaction.From {
+			fmt.Println("Error! The proposal must be executed by", p.Transaction.From)
+			return nil
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+
+		fmt.Println("Executing proposal with", approved, "approval(s) ...")
+		if err := signBlock(cCtx, z, template, kp); err != nil {
+			fmt.Println("Error signing proposal tx:", err)
+			return err
+		}
+		if err := z.Ledger.PublishRawTransaction(template); err != nil {
+			fmt.Println("Error sending proposal tx:", err)
+			return err
+		}
+
+		// record the execution before anything else can fail
+		p.Executed = template.Hash.String()
+		if err := os.MkdirAll(filepath.Dir(executedProposalPath(digest)), 0700); err != nil {
+			fmt.Println("Error recording the execution in block", p.Executed+":", err)
+			return err
+		}
+		if err := os.WriteFile(executedProposalPath(digest), []byte(p.Executed), 0600); err != nil {
+			fmt.Println("Error recording the execution in block", p.Executed+":", err)
+			return err
+		}
+		if err := writeProposal(path, p); err != nil {
+			fmt.Println("Error writing proposal:", err)
+		}
+		fmt.Println("Proposal executed in block", p.Executed)
+
+		if err := waitForBlock(cCtx, z, template); err != nil {
+			fmt.Println("Error waiting for the proposal tx:", err)
+			return err
+		}
+
+		fmt.Println("Done")
+		return nil
+	},
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+type testSigner struct {
+	key     ed25519.PrivateKey
+	address types.Address
+}
+
+func newTestSigner(seed byte) testSigner {
+	s := make([]byte, ed25519.SeedSize)
+	s[0] = seed
+	key := ed25519.NewKeyFromSeed(s)
+	return testSigner{key: key, address: types.PubKeyToAddress(key.Public().(ed25519.PublicKey))}
+}
+
+func (s testSigner) approve(digest types.Hash) proposalApproval {
+	return proposalApproval{
+		Address:   s.address.String(),
+		PublicKey: hex.EncodeToString(s.key.Public().(ed25519.PublicKey)),
+		Signature: hex.EncodeToString(ed25519.Sign(s.key, digest.Bytes())),
+	}
+}
+
+func testProposal(threshold int, signers ...testSigner) *proposal {
+	p := &proposal{Transaction: proposalTransaction{
+		ChainIdentifier: 1,
+		From:            newTestSigner(100).address.String(),
+		ToAddress:       newTestSigner(101).address.String(),
+		TokenStandard:   types.ZnnTokenStandard.String(),
+		Amount:          "100000000",
+		Nonce:           "00",
+		Threshold:       threshold,
+	}}
+	for _, s := range signers {
+		p.Transaction.Signers = append(p.Transaction.Signers, s.address.String())
+	}
+	return p
+}
+
+func TestProposalDigestCoversSignerSet(t *testing.T) {
+	alice, bob := newTestSigner(1), newTestSigner(2)
+	digest := func(p *proposal) types.Hash {
+		d, err := p.digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	base := digest(testProposal(2, alice, bob))
+	if base != digest(testProposal(2, alice, bob)) {
+		t.Fatal("digest is not deterministic")
+	}
+	if base == digest(testProposal(1, alice, bob)) {
+		t.Error("digest does not cover the threshold")
+	}
+	if base == digest(testProposal(2, alice)) {
+		t.Error("digest does not cover the signers")
+	}
+}
+
+func TestCountApprovals(t *testing.T) {
+	alice, bob, carol, mallory := newTestSigner(1), newTestSigner(2), newTestSigner(3), newTestSigner(4)
+	p := testProposal(2, alice, bob, carol)
+	digest, err := p.digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := testProposal(2, alice, bob, carol)
+	other.Transaction.Amount = "200000000"
+	otherDigest, err := other.digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := alice.approve(digest)
+	forged.Address = bob.address.String()
+	tampered := bob.approve(digest)
+	tampered.Signature = hex.EncodeToString(make([]byte, ed25519.SignatureSize))
+
+	tests := []struct {
+		name      string
+		approvals []proposalApproval
+		want      int
+	}{
+		{name: "none", want: 0},
+		{name: "two members", approvals: []proposalApproval{alice.approve(digest), bob.approve(digest)}, want: 2},
+		{name: "duplicate approval", approvals: []proposalApproval{alice.approve(digest), alice.approve(digest)}, want: 1},
+		{name: "not a member", approvals: []proposalApproval{alice.approve(digest), mallory.approve(digest)}, want: 1},
+		{name: "approval of another proposal", approvals: []proposalApproval{alice.approve(digest), bob.approve(otherDigest)}, want: 1},
+		{name: "public key of another address", approvals: []proposalApproval{forged}, want: 0},
+		{name: "invalid signature", approvals: []proposalApproval{tampered, carol.approve(digest)}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.Approvals = tt.approvals
+			set := &signerSet{Threshold: p.Transaction.Threshold, Signers: p.Transaction.Signers}
+			if got := countApprovals(p, set, digest); got != tt.want {
+				t.Errorf("got %d approvals, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignerSetValidate(t *testing.T) {
+	alice, bob := newTestSigner(1), newTestSigner(2)
+	tests := []struct {
+		name    string
+		set     signerSet
+		wantErr bool
+	}{
+		{name: "valid", set: signerSet{Threshold: 2, Signers: []string{alice.address.String(), bob.address.String()}}},
+		{name: "no threshold", set: signerSet{Threshold: 0, Signers: []string{alice.address.String(), bob.address.String()}}, wantErr: true},
+		{name: "threshold above signers", set: signerSet{Threshold: 3, Signers: []string{alice.address.String(), bob.address.String()}}, wantErr: true},
+		{name: "duplicate signers do not count", set: signerSet{Threshold: 2, Signers: []string{alice.address.String(), alice.address.String()}}, wantErr: true},
+		{name: "no signers", set: signerSet{Threshold: 1}, wantErr: true},
+		{name: "invalid signer", set: signerSet{Threshold: 1, Signers: []string{"z1invalid"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.set.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProposalCheckSignerSet(t *testing.T) {
+	alice, bob, carol, mallory := newTestSigner(1), newTestSigner(2), newTestSigner(3), newTestSigner(4)
+	operator := &signerSet{Threshold: 2, Signers: []string{alice.address.String(), bob.address.String(), carol.address.String()}}
+
+	tests := []struct {
+		name    string
+		p       *proposal
+		wantErr bool
+	}{
+		{name: "same set", p: testProposal(2, alice, bob, carol)},
+		{name: "same set in another order", p: testProposal(2, carol, alice, bob)},
+		{name: "lower threshold", p: testProposal(1, alice, bob, carol), wantErr: true},
+		{name: "missing signer", p: testProposal(2, alice, bob), wantErr: true},
+		{name: "replaced signer", p: testProposal(2, alice, bob, mallory), wantErr: true},
+		{name: "extra signer", p: testProposal(2, alice, bob, carol, mallory), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.checkSignerSet(operator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A proposal file written by an outsider with a 1-of-1 set of their own
+// key must not be executable against the operator's signer set.
+func TestForgedProposalIsRejected(t *testing.T) {
+	alice, bob, mallory := newTestSigner(1), newTestSigner(2), newTestSigner(4)
+	path := writeTestFile(t, "signers.json", `{"threshold":2,"signers":["`+alice.address.String()+`","`+bob.address.String()+`"]}`)
+	operator, err := readSignerSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged := testProposal(1, mallory)
+	digest, err := forged.digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Approvals = []proposalApproval{mallory.approve(digest)}
+
+	if err := forged.checkSignerSet(operator); err == nil {
+		t.Error("forged signer set was accepted")
+	}
+	if got := countApprovals(forged, operator, digest); got != 0 {
+		t.Errorf("got %d approvals from outside the signer set, want 0", got)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"time"
+
+	signer "github.com/ignition-pillar/go-zdk/wallet"
+	"github.com/ignition-pillar/go-zdk/zdk"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/nom"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/rpc/api"
+	"github.com/zenon-network/go-zenon/rpc/api/embedded"
+)
+
+const (
+	powAuto   = "auto"
+	powAlways = "always"
+	powNever  = "never"
+)
+
+func getRequiredPoW(z *zdk.Zdk, template *nom.AccountBlock, address types.Address) (*embedded.GetRequiredResult, error) {
+	toAddress := template.ToAddress
+	return z.Embedded.Plasma.GetRequiredPoWForAccountBlock(embedded.GetRequiredParam{
+		SelfAddr:  address,
+		BlockType: template.BlockType,
+		ToAddr:    &toAddress,
+		Data:      template.Data,
+	})
+}
+
+func sendTemplate(toAddress types.Address, zts types.ZenonTokenStandard, amount *big.Int) *nom.AccountBlock {
+	return &nom.AccountBlock{
+		BlockType:     nom.BlockTypeUserSend,
+		ToAddress:     toAddress,
+		TokenStandard: zts,
+		Amount:        amount,
+		Data:          []byte{},
+	}
+}
+
+// autofillBlock sets the account chain and momentum references of
+// template for the account of kp.
+func autofillBlock(z *zdk.Zdk, template *nom.AccountBlock, kp signer.Signer) error {
+	if template.Version == 0 {
+		template.Version = 1
+	}
+	if template.ChainIdentifier == 0 {
+		template.ChainIdentifier = uint64(chainId)
+	}
+	if template.Amount == nil {
+		template.Amount = big.NewInt(0)
+	}
+	if template.Data == nil {
+		template.Data = []byte{}
+	}
+	template.Address = kp.Address()
+	template.PublicKey = kp.PublicKey()
+
+	frontier, err := z.Ledger.GetFrontierAccountBlock(template.Address)
+	if err != nil {
+		return err
+	}
+	if frontier == nil {
+		template.Height = 1
+		template.PreviousHash = types.ZeroHash
+	} else {
+		template.Height = frontier.Height + 1
+		template.PreviousHash = frontier.Hash
+	}
+
+	momentum, err := z.Ledger.GetFrontierMomentum()
+	if err != nil {
+		return err
+	}
+	template.MomentumAcknowledged = types.HashHeight{
+		Hash:   momentum.Hash,
+		Height: momentum.Height,
+	}
+	return nil
+}
+
+// selectPlasma sets the fused plasma and PoW difficulty of block for the
+// --pow mode and the plasma required by the node. It returns an error
+// instead of letting the node reject the block when plasma is insufficient
+// and PoW is disabled.
+func selectPlasma(mode string, required *embedded.GetRequiredResult, block *nom.AccountBlock) error {
+	switch {
+	case mode == powAlways:
+		block.FusedPlasma = 0
+		block.Difficulty = required.BasePlasma * powDifficultyPerPlasma
+	case required.RequiredDifficulty == 0:
+		block.FusedPlasma = required.BasePlasma
+		block.Difficulty = 0
+	case mode == powNever:
+		return fmt.Errorf("insufficient plasma: %d available, %d required; fuse QSR to %s or use --pow %s", required.AvailablePlasma, required.BasePlasma, block.Address, powAuto)
+	default:
+		block.FusedPlasma = required.AvailablePlasma
+		block.Difficulty = required.RequiredDifficulty
+	}
+	return nil
+}
+
+// setBlockPlasma applies the --pow mode to block and generates the PoW
+// nonce when needed.
+func setBlockPlasma(cCtx *cli.Context, z *zdk.Zdk, block *nom.AccountBlock) error {
+	mode := cCtx.String("pow")
+	if mode != powAuto && mode != powAlways && mode != powNever {
+		return fmt.Errorf("invalid --pow mode %q, expected %s, %s or %s", mode, powAuto, powAlways, powNever)
+	}
+
+	required, err := getRequiredPoW(z, block, block.Address)
+	if err != nil {
+		return err
+	}
+	if err := selectPlasma(mode, required, block); err != nil {
+		return err
+	}
+	if block.Difficulty == 0 {
+		return nil
+	}
+	if mode == powAuto {
+		fmt.Println("Insufficient plasma, generating PoW with difficulty", block.Difficulty)
+	}
+
+	ctx, stop := signal.NotifyContext(cCtx.Context, os.Interrupt)
+	defer stop()
+	nonce, err := generatePoW(ctx, powDataHash(block.Address, block.PreviousHash), block.Difficulty, cCtx.Int("pow-threads"))
+	if err != nil {
+		return err
+	}
+	block.Nonce.Data = nonce
+	return nil
+}
+
+// signBlock autofills template for the account of kp, applies the --pow
+// mode and signs it.
+func signBlock(cCtx *cli.Context, z *zdk.Zdk, template *nom.AccountBlock, kp signer.Signer) error {
+	if err := autofillBlock(z, template, kp); err != nil {
+		return err
+	}
+	if err := setBlockPlasma(cCtx, z, template); err != nil {
+		return err
+	}
+	template.Hash = template.ComputeHash()
+	var signature []byte
+	var err error
+	if bs, ok := kp.(blockSigner); ok {
+		signature, err = bs.SignBlock(template)
+	} else {
+		signature, err = kp.Sign(template.Hash.Bytes())
+	}
+	if err != nil {
+		return err
+	}
+	template.Signature = signature
+	return nil
+}
+
+// waitForBlock blocks until block is confirmed by the requested number of
+// momentums when --wait is set.
+func waitForBlock(cCtx *cli.Context, z *zdk.Zdk, block *nom.AccountBlock) error {
+	if !cCtx.Bool("wait") {
+		return nil
+	}
+	confirmations := cCtx.Uint64("confirmations")
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	fmt.Println("Waiting for", block.Hash, "to reach", confirmations, "confirmation(s) ...")
+	detail, err := waitForConfirmation(z, block.Hash, confirmations, cCtx.Duration("timeout"))
+	if err != nil {
+		return err
+	}
+	fmt.Println("Confirmed in momentum", detail.MomentumHeight, detail.MomentumHash, "with", detail.NumConfirmations, "confirmation(s)")
+	return nil
+}
+
+// sendBlock signs and publishes template and waits for it when --wait is
+// set. When only the wait fails the published block is returned with the
+// error.
+func sendBlock(cCtx *cli.Context, z *zdk.Zdk, template *nom.AccountBlock, kp signer.Signer) (*nom.AccountBlock, error) {
+	if err := signBlock(cCtx, z, template, kp); err != nil {
+		return nil, err
+	}
+	if err := z.Ledger.PublishRawTransaction(template); err != nil {
+		return nil, err
+	}
+	return template, waitForBlock(cCtx, z, template)
+}
+
+// waitForConfirmation polls the account block with the given hash until
+// it has at least confirmations momentum confirmations or timeout elapses.
+func waitForConfirmation(z *zdk.Zdk, hash types.Hash, confirmations uint64, timeout time.Duration) (*api.AccountBlockConfirmationDetail, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		block, err := z.Ledger.GetAccountBlockByHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		if block != nil && block.ConfirmationDetail != nil && block.ConfirmationDetail.NumConfirmations >= confirmations {
+			return block.ConfirmationDetail, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %v waiting for %d confirmation(s) of %s", timeout, confirmations, hash)
+		}
+		time.Sleep(momentumPollInterval)
+	}
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/nom"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+type batchEntry struct {
+	Address string `json:"address"`
+	Token   string `json:"token"`
+	Amount  string `json:"amount"`
+
+	// line is the line of a CSV entry in the batch file, 0 for JSON
+	line int
+}
+
+// position names the n-th entry of the batch file the way an editor shows
+// it, by line for CSV files and by entry for JSON arrays.
+func (e batchEntry) position(n int) string {
+	if e.line > 0 {
+		return fmt.Sprintf("line %d", e.line)
+	}
+	return fmt.Sprintf("entry %d", n)
+}
+
+// batchPayment is a validated entry. Rows number the entries from 1 and key
+// the checkpoint.
+type batchPayment struct {
+	row       int
+	position  string
+	toAddress types.Address
+	zts       types.ZenonTokenStandard
+	amount    *big.Int
+}
+
+// batchCheckpoint records which rows of a batch file have been sent so an
+// interrupted batch can be resumed without paying anyone twice. A payment
+// is recorded as pending before it is published and as sent once it is.
+type batchCheckpoint struct {
+	FileHash string         `json:"fileHash"`
+	Sender   string         `json:"sender"`
+	Sent     map[int]string `json:"sent"`
+	Pending  *batchPending  `json:"pending,omitempty"`
+}
+
+// batchPending is a payment that may have been published. Its blocks are
+// all for the same account height, so at most one of them can be on the
+// account chain.
+type batchPending struct {
+	Row    int      `json:"row"`
+	Height uint64   `json:"height"`
+	Hashes []string `json:"hashes"`
+}
+
+// readBatchFile reads address,token,amount rows from a CSV file, or an
+// array of entries from a file with a .json extension.
+func readBatchFile(path string) ([]batchEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []batchEntry
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.NewDecoder(f).Decode(&entries); err != nil {
+			return nil, err
+		}
+		return entries, nil
+	}
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 3
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 && strings.EqualFold(record[0], "address") {
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		entries = append(entries, batchEntry{
+			Address: strings.TrimSpace(record[0]),
+			Token:   strings.TrimSpace(record[1]),
+			Amount:  strings.TrimSpace(record[2]),
+			line:    line,
+		})
+	}
+	return entries, nil
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func readBatchCheckpoint(path string) (*batchCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &batchCheckpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, err
+	}
+	if checkpoint.Sent == nil {
+		checkpoint.Sent = make(map[int]string)
+	}
+	return checkpoint, nil
+}
+
+// writeBatchCheckpoint replaces the checkpoint file atomically so a crash
+// never leaves it truncated.
+func writeBatchCheckpoint(path string, checkpoint *batchCheckpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "    ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// resolveBatchPending settles the pending payment of checkpoint. It is sent
+// when one of its blocks is on the account chain and is dropped when the
+// account chain already reached its height with another block. Otherwise
+// it stays pending and its row is sent again at the same height.
+func resolveBatchPending(checkpoint *batchCheckpoint, published func(types.Hash) (bool, error), frontierHeight uint64) error {
+	pending := checkpoint.Pending
+	if pending == nil {
+		return nil
+	}
+	for _, h := range pending.Hashes {
+		hash, err := types.HexToHash(h)
+		if err != nil {
+			return fmt.Errorf("invalid pending block hash %q: %w", h, err)
+		}
+		ok, err := published(hash)
+		if err != nil {
+			return err
+		}
+		if ok {
+			checkpoint.Sent[pending.Row] = h
+			checkpoint.Pending = nil
+			return nil
+		}
+	}
+	if frontierHeight >= pending.Height {
+		checkpoint.Pending = nil
+	}
+	return nil
+}
+
+// addBatchPending records block as a pending payment of row.
+func addBatchPending(checkpoint *batchCheckpoint, row int, block *nom.AccountBlock) {
+	pending := checkpoint.Pending
+	if pending == nil || pending.Row != row || pending.Height != block.Height {
+		pending = &batchPending{Row: row, Height: block.Height}
+		checkpoint.Pending = pending
+	}
+	pending.Hashes = append(pending.Hashes, block.Hash.String())
+}
+
+var znnCliSendBatch = &cli.Command{
+	Name:  "send.batch",
+	Usage: "file.csv|file.json",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "checkpoint",
+			Usage: "Checkpoint file used to resume the batch, defaults to the batch file path with a .checkpoint suffix",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only validate the batch file and print the totals",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("send.batch file.csv|file.json")
+			return nil
+		}
+		path := cCtx.Args().Get(0)
+		entries, err := readBatchFile(path)
+		if err != nil {
+			fmt.Println("Error reading batch file:", err)
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("Error! The batch file has no entries")
+			return nil
+		}
+
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+		info, err := z.Ledger.GetAccountInfoByAddress(kp.Address())
+		if err != nil {
+			fmt.Println("Error getting account info:", err)
+			return err
+		}
+
+		// validate every row before sending anything
+		payments := make([]batchPayment, 0, len(entries))
+		invalid := 0
+		for i, entry := range entries {
+			row := i + 1
+			position := entry.position(row)
+			toAddress, err := resolveAddress(entry.Address)
+			if err != nil {
+				fmt.Println("Invalid address on", position, entry.Address+":", err)
+				invalid++
+				continue
+			}
+			zts, err := parseTokenStandard(entry.Token)
+			if err != nil {
+				fmt.Println("Invalid token on", position, entry.Token+":", err)
+				invalid++
+				continue
+			}
+			balance, ok := info.BalanceInfoMap[zts]
+			if !ok {
+				fmt.Println("Token", zts.String(), "on", position, "is not held by", kp.Address())
+				invalid++
+				continue
+			}
+			amount, err := parseAmount(entry.Amount, balance.TokenInfo.Decimals)
+			if err != nil {
+				fmt.Println("Invalid amount on", position+":", err)
+				invalid++
+				continue
+			}
+			if amount.Sign() <= 0 {
+				fmt.Println("Amount on", position, "must be greater than 0")
+				invalid++
+				continue
+			}
+			payments = append(payments, batchPayment{row: row, position: position, toAddress: toAddress, zts: zts, amount: amount})
+		}
+		if invalid > 0 {
+			fmt.Println("Error!", invalid, "invalid entries, nothing was sent")
+			return errors.New("invalid batch file")
+		}
+
+		checkpointPath := cCtx.String("checkpoint")
+		if checkpointPath == "" {
+			checkpointPath = path + ".checkpoint"
+		}
+		fileHash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		checkpoint, err := readBatchCheckpoint(checkpointPath)
+		if err != nil {
+			fmt.Println("Error reading checkpoint:", err)
+			return err
+		}
+		if checkpoint == nil {
+			checkpoint = &batchCheckpoint{
+				FileHash: fileHash,
+				Sender:   kp.Address().String(),
+				Sent:     make(map[int]string),
+			}
+		} else {
+			if checkpoint.FileHash != fileHash {
+				fmt.Println("Error! The checkpoint", checkpointPath, "belongs to a different batch file")
+				return errors.New("checkpoint mismatch")
+			}
+			if checkpoint.Sender != kp.Address().String() {
+				fmt.Println("Error! The checkpoint", checkpointPath, "was created by", checkpoint.Sender)
+				return errors.New("checkpoint mismatch")
+			}
+			if checkpoint.Pending != nil {
+				row := checkpoint.Pending.Row
+				position := fmt.Sprintf("row %d", row)
+				if row >= 1 && row <= len(entries) {
+					position = entries[row-1].position(row)
+				}
+				frontier, err := z.Ledger.GetFrontierAccountBlock(kp.Address())
+				if err != nil {
+					fmt.Println("Error getting frontier account block:", err)
+					return err
+				}
+				frontierHeight := uint64(0)
+				if frontier != nil {
+					frontierHeight = frontier.Height
+				}
+				published := func(hash types.Hash) (bool, error) {
+					block, err := z.Ledger.GetAccountBlockByHash(hash)
+					return block != nil, err
+				}
+				if err := resolveBatchPending(checkpoint, published, frontierHeight); err != nil {
+					fmt.Println("Error checking the pending payment of", position, "-", err)
+					return err
+				}
+				if err := writeBatchCheckpoint(checkpointPath, checkpoint); err != nil {
+					fmt.Println("Error writing checkpoint:", err)
+					return err
+				}
+				if _, ok := checkpoint.Sent[row]; ok {
+					fmt.Println("The payment of", position, "was published by the previous run")
+				} else {
+					fmt.Println("The payment of", position, "was not published by the previous run and will be sent")
+				}
+			}
+			fmt.Println("Resuming batch,", len(checkpoint.Sent), "of", len(payments), "payment(s) already sent")
+		}
+
+		// totals of the payments still to be sent
+		totals := make(map[types.ZenonTokenStandard]*big.Int)
+		remaining := 0
+		for _, p := range payments {
+			if _, ok := checkpoint.Sent[p.row]; ok {
+				continue
+			}
+			if _, ok := totals[p.zts]; !ok {
+				totals[p.zts] = big.NewInt(0)
+			}
+			totals[p.zts].Add(totals[p.zts], p.amount)
+			remaining++
+		}
+		insufficient := false
+		for zts, total := range totals {
+			balance := info.BalanceInfoMap[zts]
+			fmt.Println("Total:", formatAmount(total, balance.TokenInfo.Decimals), balance.TokenInfo.TokenSymbol, "of", formatAmount(balance.Balance, balance.TokenInfo.Decimals), "available")
+			if balance.Balance.Cmp(total) < 0 {
+				insufficient = true
+			}
+		}
+		if insufficient {
+			fmt.Println("Error! Insufficient balance for the batch, nothing was sent")
+			return errors.New("insufficient balance")
+		}
+		if cCtx.Bool("dry-run") || remaining == 0 {
+			fmt.Println(remaining, "payment(s) to send")
+			return nil
+		}
+
+		fmt.Println("Sending", remaining, "payment(s) ...")
+		for _, p := range payments {
+			if _, ok := checkpoint.Sent[p.row]; ok {
+				continue
+			}
+			balance := info.BalanceInfoMap[p.zts]
+			block := sendTemplate(p.toAddress, p.zts, p.amount)
+			if err := signBlock(cCtx, z, block, kp); err != nil {
+				fmt.Println("Error signing the payment of", p.position, "to", p.toAddress.String()+":", err)
+				fmt.Println("Run the same command again to resume from", p.position)
+				return err
+			}
+			addBatchPending(checkpoint, p.row, block)
+			if err := writeBatchCheckpoint(checkpointPath, checkpoint); err != nil {
+				fmt.Println("Error writing checkpoint before the payment of", p.position, "was sent:", err)
+				return err
+			}
+			if err := z.Ledger.PublishRawTransaction(block); err != nil {
+				fmt.Println("Error sending the payment of", p.position, "to", p.toAddress.String()+":", err)
+				fmt.Println("Run the same command again to resume from", p.position)
+				return err
+			}
+			checkpoint.Sent[p.row] = block.Hash.String()
+			checkpoint.Pending = nil
+			if err := writeBatchCheckpoint(checkpointPath, checkpoint); err != nil {
+				fmt.Println("Error writing checkpoint after the payment of", p.position, "was sent:", err)
+				return err
+			}
+			fmt.Println("Sent", p.position+":", formatAmount(p.amount, balance.TokenInfo.Decimals), balance.TokenInfo.TokenSymbol, "to", p.toAddress, block.Hash)
+			if err := waitForBlock(cCtx, z, block); err != nil {
+				fmt.Println("Error waiting for the payment of", p.position, "to be confirmed:", err)
+				fmt.Println("The payment was sent, run the same command again to continue after it")
+				return err
+			}
+		}
+
+		fmt.Println("Done")
+		return nil
+	},
+}
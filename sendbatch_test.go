@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zenon-network/go-zenon/chain/nom"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadBatchFile(t *testing.T) {
+	want := []batchEntry{
+		{Address: "z1first", Token: "ZNN", Amount: "1.5"},
+		{Address: "z1second", Token: "QSR", Amount: "1_000"},
+	}
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []batchEntry
+		wantErr bool
+	}{
+		{name: "csv", file: "batch.csv", content: "z1first,ZNN,1.5\nz1second,QSR,1_000\n", want: atLines(want, 1, 2)},
+		{name: "csv with header, comments and spaces", file: "batch.csv", content: "address,token,amount\n# payouts\nz1first, ZNN , 1.5\n\nz1second,QSR,1_000\n", want: atLines(want, 3, 5)},
+		{name: "csv with a missing field", file: "batch.csv", content: "z1first,ZNN\n", wantErr: true},
+		{name: "csv with an extra field", file: "batch.csv", content: "z1first,ZNN,1,2\n", wantErr: true},
+		{name: "empty csv", file: "batch.csv", content: ""},
+		{name: "json", file: "batch.JSON", content: `[{"address":"z1first","token":"ZNN","amount":"1.5"},{"address":"z1second","token":"QSR","amount":"1_000"}]`, want: want},
+		{name: "invalid json", file: "batch.json", content: `{"address":"z1first"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := readBatchFile(writeTestFile(t, tt.file, tt.content))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("got %+v, want %+v", entries, tt.want)
+			}
+		})
+	}
+}
+
+func atLines(entries []batchEntry, lines ...int) []batchEntry {
+	withLines := make([]batchEntry, len(entries))
+	for i, e := range entries {
+		e.line = lines[i]
+		withLines[i] = e
+	}
+	return withLines
+}
+
+func TestBatchEntryPosition(t *testing.T) {
+	if got := (batchEntry{line: 3}).position(1); got != "line 3" {
+		t.Errorf("got %q for a CSV entry, want line 3", got)
+	}
+	if got := (batchEntry{}).position(2); got != "entry 2" {
+		t.Errorf("got %q for a JSON entry, want entry 2", got)
+	}
+}
+
+func TestBatchCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.csv.checkpoint")
+	checkpoint, err := readBatchCheckpoint(path)
+	if err != nil || checkpoint != nil {
+		t.Fatalf("missing checkpoint: got %v, %v", checkpoint, err)
+	}
+
+	want := &batchCheckpoint{
+		FileHash: "abc",
+		Sender:   "z1sender",
+		Sent:     map[int]string{1: types.NewHash([]byte("1")).String()},
+		Pending:  &batchPending{Row: 2, Height: 8, Hashes: []string{types.NewHash([]byte("2")).String()}},
+	}
+	if err := writeBatchCheckpoint(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readBatchCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temporary checkpoint file was left behind")
+	}
+}
+
+func TestResolveBatchPending(t *testing.T) {
+	first := types.NewHash([]byte("first"))
+	second := types.NewHash([]byte("second"))
+	pending := func() *batchPending {
+		return &batchPending{Row: 3, Height: 10, Hashes: []string{first.String(), second.String()}}
+	}
+
+	tests := []struct {
+		name        string
+		pending     *batchPending
+		onChain     map[types.Hash]bool
+		frontier    uint64
+		lookupErr   error
+		wantSent    string
+		wantPending bool
+		wantErr     bool
+	}{
+		{name: "nothing pending"},
+		{name: "first block published", pending: pending(), onChain: map[types.Hash]bool{first: true}, frontier: 10, wantSent: first.String()},
+		{name: "retried block published", pending: pending(), onChain: map[types.Hash]bool{second: true}, frontier: 12, wantSent: second.String()},
+		{name: "height taken by another block", pending: pending(), frontier: 10},
+		{name: "not published yet", pending: pending(), frontier: 9, wantPending: true},
+		{name: "lookup fails", pending: pending(), lookupErr: errors.New("connection lost"), wantPending: true, wantErr: true},
+		{name: "invalid hash", pending: &batchPending{Row: 3, Height: 10, Hashes: []string{"zz"}}, wantPending: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpoint := &batchCheckpoint{Sent: map[int]string{1: "x", 2: "y"}, Pending: tt.pending}
+			published := func(hash types.Hash) (bool, error) {
+				return tt.onChain[hash], tt.lookupErr
+			}
+			err := resolveBatchPending(checkpoint, published, tt.frontier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if (checkpoint.Pending != nil) != tt.wantPending {
+				t.Errorf("got pending %+v, want pending %v", checkpoint.Pending, tt.wantPending)
+			}
+			sent, ok := checkpoint.Sent[3]
+			if tt.wantSent == "" && ok {
+				t.Errorf("row 3 recorded as sent in %s", sent)
+			}
+			if tt.wantSent != "" && sent != tt.wantSent {
+				t.Errorf("row 3 recorded as sent in %q, want %q", sent, tt.wantSent)
+			}
+			if len(checkpoint.Sent) < 2 {
+				t.Error("rows sent before were dropped")
+			}
+		})
+	}
+}
+
+func TestAddBatchPending(t *testing.T) {
+	block := func(height uint64, name string) *nom.AccountBlock {
+		return &nom.AccountBlock{Height: height, Hash: types.NewHash([]byte(name))}
+	}
+	checkpoint := &batchCheckpoint{Sent: map[int]string{}}
+
+	addBatchPending(checkpoint, 1, block(5, "a"))
+	addBatchPending(checkpoint, 1, block(5, "b"))
+	want := &batchPending{Row: 1, Height: 5, Hashes: []string{block(5, "a").Hash.String(), block(5, "b").Hash.String()}}
+	if !reflect.DeepEqual(checkpoint.Pending, want) {
+		t.Fatalf("retry at the same height: got %+v, want %+v", checkpoint.Pending, want)
+	}
+
+	addBatchPending(checkpoint, 1, block(6, "c"))
+	want = &batchPending{Row: 1, Height: 6, Hashes: []string{block(6, "c").Hash.String()}}
+	if !reflect.DeepEqual(checkpoint.Pending, want) {
+		t.Fatalf("retry at another height: got %+v, want %+v", checkpoint.Pending, want)
+	}
+
+	addBatchPending(checkpoint, 2, block(6, "d"))
+	want = &batchPending{Row: 2, Height: 6, Hashes: []string{block(6, "d").Hash.String()}}
+	if !reflect.DeepEqual(checkpoint.Pending, want) {
+		t.Fatalf("next row: got %+v, want %+v", checkpoint.Pending, want)
+	}
+}
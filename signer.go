@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	signer "github.com/ignition-pillar/go-zdk/wallet"
+	"github.com/zenon-network/go-zenon/chain/nom"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// External signers keep key material out of nomctl. They are selected with
+// --signer and spoken to with JSON-RPC 2.0, either over HTTP (also on a
+// unix socket) or by running a command that reads one request on stdin and
+// writes one response on stdout.
+
+const signerKeyFile = "keyfile"
+
+const (
+	signerMethodAccount   = "signer_account"
+	signerMethodSign      = "signer_sign"
+	signerMethodSignBlock = "signer_signAccountBlock"
+)
+
+const signerTimeout = 30 * time.Second
+
+type signerRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      int             `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type signerRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type signerRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *signerRPCError `json:"error,omitempty"`
+}
+
+// signerKeyParams selects a key of the signing service, mirroring the
+// --keyStore and --index flags of the keyfile signer.
+type signerKeyParams struct {
+	KeyStore string `json:"keyStore,omitempty"`
+	Index    uint32 `json:"index"`
+}
+
+type signerAccountResult struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"publicKey"`
+}
+
+type signerSignParams struct {
+	signerKeyParams
+	Data string `json:"data"`
+}
+
+type signerSignBlockParams struct {
+	signerKeyParams
+	Block *nom.AccountBlock `json:"block"`
+}
+
+type signerSignResult struct {
+	Signature string `json:"signature"`
+}
+
+type signerTransport interface {
+	call(method string, params interface{}, result interface{}) error
+}
+
+func encodeSignerRequest(method string, params interface{}) ([]byte, error) {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(signerRPCRequest{JSONRPC: "2.0", Id: 1, Method: method, Params: p})
+}
+
+func decodeSignerResponse(data []byte, result interface{}) error {
+	var resp signerRPCResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("invalid signer response: %w", err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("signer error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+type httpSignerTransport struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (t *httpSignerTransport) call(method string, params interface{}, result interface{}) error {
+	body, err := encodeSignerRequest(method, params)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return decodeSignerResponse(data, result)
+}
+
+type execSignerTransport struct {
+	command []string
+}
+
+func (t *execSignerTransport) call(method string, params interface{}, result interface{}) error {
+	body, err := encodeSignerRequest(method, params)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), signerTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, t.command[0], t.command[1:]...)
+	cmd.Stdin = bytes.NewReader(append(body, '\n'))
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("signer command failed: %w", err)
+	}
+	return decodeSignerResponse(out, result)
+}
+
+// newSignerTransport parses a --signer value: unix:<socket path>,
+// http(s)://<endpoint> or exec:<command and arguments>.
+func newSignerTransport(uri string) (signerTransport, error) {
+	switch {
+	case strings.HasPrefix(uri, "unix:"):
+		path := strings.TrimPrefix(uri, "unix:")
+		return &httpSignerTransport{
+			client: &http.Client{
+				Timeout: signerTimeout,
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+						return (&net.Dialer{}).DialContext(ctx, "unix", path)
+					},
+				},
+			},
+			endpoint: "http://unix/",
+		}, nil
+	case strings.HasPrefix(uri, "http://"), strings.HasPrefix(uri, "https://"):
+		return &httpSignerTransport{
+			client:   &http.Client{Timeout: signerTimeout},
+			endpoint: uri,
+		}, nil
+	case strings.HasPrefix(uri, "exec:"):
+		command := strings.Fields(strings.TrimPrefix(uri, "exec:"))
+		if len(command) == 0 {
+			return nil, errors.New("exec signer requires a command")
+		}
+		return &execSignerTransport{command: command}, nil
+	}
+	return nil, fmt.Errorf("unsupported signer %q, expected %s, unix:<path>, http(s)://<url> or exec:<command>", uri, signerKeyFile)
+}
+
+// blockSigner is implemented by signers that want the whole account block
+// instead of only its hash, for example to apply signing policies.
+type blockSigner interface {
+	SignBlock(block *nom.AccountBlock) ([]byte, error)
+}
+
+// remoteSigner is a signer.Signer backed by an external signing service.
+// Every signature it returns is verified against the account public key.
+type remoteSigner struct {
+	transport signerTransport
+	key       signerKeyParams
+	address   types.Address
+	publicKey ed25519.PublicKey
+}
+
+var _ signer.Signer = (*remoteSigner)(nil)
+var _ blockSigner = (*remoteSigner)(nil)
+
+func newRemoteSigner(uri string, keyStore string, index uint32) (*remoteSigner, error) {
+	transport, err := newSignerTransport(uri)
+	if err != nil {
+		return nil, err
+	}
+	key := signerKeyParams{KeyStore: keyStore, Index: index}
+	var account signerAccountResult
+	if err := transport.call(signerMethodAccount, key, &account); err != nil {
+		return nil, err
+	}
+	address, err := types.ParseAddress(account.Address)
+	if err != nil {
+		return nil, fmt.Errorf("signer returned an invalid address: %w", err)
+	}
+	publicKey, err := hex.DecodeString(account.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("signer returned an invalid public key")
+	}
+	if types.PubKeyToAddress(publicKey) != address {
+		return nil, errors.New("signer public key does not match its address")
+	}
+	return &remoteSigner{
+		transport: transport,
+		key:       key,
+		address:   address,
+		publicKey: publicKey,
+	}, nil
+}
+
+func (s *remoteSigner) Address() types.Address {
+	return s.address
+}
+
+func (s *remoteSigner) PublicKey() ed25519.PublicKey {
+	return s.publicKey
+}
+
+func (s *remoteSigner) verify(data []byte, result signerSignResult) ([]byte, error) {
+	signature, err := hex.DecodeString(result.Signature)
+	if err != nil {
+		return nil, errors.New("signer returned an invalid signature")
+	}
+	if !ed25519.Verify(s.publicKey, data, signature) {
+		return nil, errors.New("signer returned a signature that does not verify")
+	}
+	return signature, nil
+}
+
+func (s *remoteSigner) Sign(data []byte) ([]byte, error) {
+	var result signerSignResult
+	params := signerSignParams{signerKeyParams: s.key, Data: hex.EncodeToString(data)}
+	if err := s.transport.call(signerMethodSign, params, &result); err != nil {
+		return nil, err
+	}
+	return s.verify(data, result)
+}
+
+func (s *remoteSigner) SignBlock(block *nom.AccountBlock) ([]byte, error) {
+	var result signerSignResult
+	params := signerSignBlockParams{signerKeyParams: s.key, Block: block}
+	if err := s.transport.call(signerMethodSignBlock, params, &result); err != nil {
+		return nil, err
+	}
+	return s.verify(block.Hash.Bytes(), result)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zenon-network/go-zenon/chain/nom"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// signerPolicy restricts what each client of the signing server may have
+// signed. Clients are identified by the user id of the process connecting
+// to the unix socket and anything not listed is refused. Every rule of a
+// client only applies when it is set.
+type signerPolicy struct {
+	Clients []*signerClientPolicy `json:"clients"`
+}
+
+type signerClientPolicy struct {
+	// Uid is the user id of the client process.
+	Uid int `json:"uid"`
+	// KeyStores the client may use, all loaded keyStores when empty.
+	KeyStores []string `json:"keyStores"`
+	// MaxAmount per transaction keyed by ZNN, QSR or a token standard, in
+	// base units of the token for every token, e.g. "5e8" for 5 ZNN. When
+	// set, tokens that are not listed cannot be sent.
+	MaxAmount map[string]string `json:"maxAmount"`
+	// AllowedDestinations for sends to non embedded addresses.
+	AllowedDestinations []string `json:"allowedDestinations"`
+	// AllowedMethods for calls to embedded contracts, each either
+	// <contract>.<Signature> such as pillar.CollectReward() or
+	// <contract>.0x<selector>. No embedded calls are allowed when empty.
+	AllowedMethods []string `json:"allowedMethods"`
+	// RateLimit caps the number of signatures per interval.
+	RateLimit *signerRateLimit `json:"rateLimit"`
+	// AllowMessageSigning permits signing arbitrary data. Arbitrary data
+	// may be a block hash, so this bypasses every other rule.
+	AllowMessageSigning bool `json:"allowMessageSigning"`
+
+	maxAmount    map[types.ZenonTokenStandard]*big.Int
+	destinations map[types.Address]bool
+	methods      map[types.Address][][]byte
+	keyStores    map[string]bool
+
+	mu     sync.Mutex
+	signed []time.Time
+}
+
+type signerRateLimit struct {
+	Count    int    `json:"count"`
+	Interval string `json:"interval"`
+
+	interval time.Duration
+}
+
+func readSignerPolicy(path string) (*signerPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &signerPolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, err
+	}
+	uids := make(map[int]bool)
+	for _, c := range policy.Clients {
+		if uids[c.Uid] {
+			return nil, fmt.Errorf("duplicate policy for uid %d", c.Uid)
+		}
+		uids[c.Uid] = true
+		if err := c.parse(); err != nil {
+			return nil, fmt.Errorf("policy for uid %d: %w", c.Uid, err)
+		}
+	}
+	return policy, nil
+}
+
+func (p *signerPolicy) client(uid int) *signerClientPolicy {
+	for _, c := range p.Clients {
+		if c.Uid == uid {
+			return c
+		}
+	}
+	return nil
+}
+
+func parseMethodSelector(s string) (types.Address, []byte, error) {
+	contract, method, ok := strings.Cut(s, ".")
+	if !ok {
+		return types.Address{}, nil, fmt.Errorf("invalid method %q, expected <contract>.<method>", s)
+	}
+	var address types.Address
+	found := false
+	for a, name := range embeddedContractNames {
+		if name == contract {
+			address, found = a, true
+		}
+	}
+	if !found {
+		return types.Address{}, nil, fmt.Errorf("unknown embedded contract %q", contract)
+	}
+	if strings.HasPrefix(method, "0x") {
+		selector, err := hex.DecodeString(method[2:])
+		if err != nil || len(selector) != 4 {
+			return types.Address{}, nil, fmt.Errorf("invalid method selector %q", method)
+		}
+		return address, selector, nil
+	}
+	return address, types.NewHash([]byte(method)).Bytes()[:4], nil
+}
+
+func (c *signerClientPolicy) parse() error {
+	c.keyStores = make(map[string]bool)
+	for _, name := range c.KeyStores {
+		c.keyStores[name] = true
+	}
+	c.maxAmount = make(map[types.ZenonTokenStandard]*big.Int)
+	for token, amount := range c.MaxAmount {
+		zts, err := parseTokenStandard(token)
+		if err != nil {
+			return err
+		}
+		max, err := parseAmount(amount, 0)
+		if err != nil {
+			return fmt.Errorf("max amount of %s: %w", token, err)
+		}
+		c.maxAmount[zts] = max
+	}
+	c.destinations = make(map[types.Address]bool)
+	for _, d := range c.AllowedDestinations {
+		address, err := types.ParseAddress(d)
+		if err != nil {
+			return err
+		}
+		c.destinations[address] = true
+	}
+	c.methods = make(map[types.Address][][]byte)
+	for _, m := range c.AllowedMethods {
+		address, selector, err := parseMethodSelector(m)
+		if err != nil {
+			return err
+		}
+		c.methods[address] = append(c.methods[address], selector)
+	}
+	if c.RateLimit != nil {
+		interval, err := time.ParseDuration(c.RateLimit.Interval)
+		if err != nil {
+			return err
+		}
+		if c.RateLimit.Count < 1 || interval <= 0 {
+			return errors.New("rate limit count and interval must be positive")
+		}
+		c.RateLimit.interval = interval
+	}
+	return nil
+}
+
+func (c *signerClientPolicy) checkKeyStore(name string) error {
+	if len(c.keyStores) != 0 && !c.keyStores[name] {
+		return fmt.Errorf("keyStore %q is not allowed", name)
+	}
+	return nil
+}
+
+func (c *signerClientPolicy) checkBlock(block *nom.AccountBlock) error {
+	if block.BlockType != nom.BlockTypeUserSend {
+		return nil
+	}
+	if block.Amount != nil && block.Amount.Sign() > 0 && len(c.maxAmount) != 0 {
+		max, ok := c.maxAmount[block.TokenStandard]
+		if !ok {
+			return fmt.Errorf("sending %s is not allowed", block.TokenStandard)
+		}
+		if block.Amount.Cmp(max) > 0 {
+			return fmt.Errorf("amount %s exceeds the maximum of %s", block.Amount, max)
+		}
+	}
+	if types.IsEmbeddedAddress(block.ToAddress) {
+		if len(block.Data) < 4 {
+			return fmt.Errorf("call to %s without a method is not allowed", block.ToAddress)
+		}
+		for _, selector := range c.methods[block.ToAddress] {
+			if string(selector) == string(block.Data[:4]) {
+				return nil
+			}
+		}
+		return fmt.Errorf("method 0x%s of %s is not allowed", hex.EncodeToString(block.Data[:4]), block.ToAddress)
+	}
+	if len(c.destinations) != 0 && !c.destinations[block.ToAddress] {
+		return fmt.Errorf("destination %s is not allowed", block.ToAddress)
+	}
+	return nil
+}
+
+// allow records a signature against the rate limit, refusing it when the
+// limit has been reached.
+func (c *signerClientPolicy) allow() error {
+	if c.RateLimit == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	recent := c.signed[:0]
+	for _, t := range c.signed {
+		if now.Sub(t) < c.RateLimit.interval {
+			recent = append(recent, t)
+		}
+	}
+	c.signed = recent
+	if len(c.signed) >= c.RateLimit.Count {
+		return fmt.Errorf("rate limit of %d signature(s) per %s reached", c.RateLimit.Count, c.RateLimit.interval)
+	}
+	c.signed = append(c.signed, now)
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zenon-network/go-zenon/chain/nom"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+func testAddress(seed byte) types.Address {
+	s := make([]byte, ed25519.SeedSize)
+	s[0] = seed
+	return types.PubKeyToAddress(ed25519.NewKeyFromSeed(s).Public().(ed25519.PublicKey))
+}
+
+func readTestPolicy(t *testing.T, content string) (*signerPolicy, error) {
+	t.Helper()
+	return readSignerPolicy(writeTestFile(t, "policy.json", content))
+}
+
+func TestReadSignerPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "valid", content: `{"clients":[{"uid":1000,"maxAmount":{"ZNN":"5e8"},"rateLimit":{"count":1,"interval":"1m"}}]}`},
+		{name: "duplicate uid", content: `{"clients":[{"uid":1000},{"uid":1000}]}`, wantErr: "duplicate policy"},
+		{name: "fractional base units", content: `{"clients":[{"uid":1000,"maxAmount":{"ZNN":"1.5"}}]}`, wantErr: "max amount of ZNN"},
+		{name: "unknown token", content: `{"clients":[{"uid":1000,"maxAmount":{"FOO":"1"}}]}`, wantErr: "policy for uid 1000"},
+		{name: "invalid destination", content: `{"clients":[{"uid":1000,"allowedDestinations":["z1invalid"]}]}`, wantErr: "policy for uid 1000"},
+		{name: "unknown contract", content: `{"clients":[{"uid":1000,"allowedMethods":["vault.Open()"]}]}`, wantErr: "unknown embedded contract"},
+		{name: "invalid selector", content: `{"clients":[{"uid":1000,"allowedMethods":["pillar.0x1234"]}]}`, wantErr: "invalid method selector"},
+		{name: "zero rate limit", content: `{"clients":[{"uid":1000,"rateLimit":{"count":0,"interval":"1m"}}]}`, wantErr: "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readTestPolicy(t, tt.content)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignerPolicyCheckBlock(t *testing.T) {
+	allowed, other := testAddress(1), testAddress(2)
+	token := types.NewZenonTokenStandard([]byte("token"))
+	policy, err := readTestPolicy(t, `{"clients":[
+		{"uid":1,
+		 "maxAmount":{"ZNN":"5e8","`+token.String()+`":"1000"},
+		 "allowedDestinations":["`+allowed.String()+`"],
+		 "allowedMethods":["pillar.CollectReward()","plasma.0x01020304"]},
+		{"uid":2}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restricted, open := policy.client(1), policy.client(2)
+	if policy.client(3) != nil {
+		t.Fatal("unlisted uid has a policy")
+	}
+
+	send := func(to types.Address, zts types.ZenonTokenStandard, amount int64, data []byte) *nom.AccountBlock {
+		return &nom.AccountBlock{BlockType: nom.BlockTypeUserSend, ToAddress: to, TokenStandard: zts, Amount: big.NewInt(amount), Data: data}
+	}
+	collectReward := types.NewHash([]byte("CollectReward()")).Bytes()[:4]
+
+	tests := []struct {
+		name    string
+		client  *signerClientPolicy
+		block   *nom.AccountBlock
+		wantErr string
+	}{
+		{name: "receive", client: restricted, block: &nom.AccountBlock{BlockType: nom.BlockTypeUserReceive}},
+		{name: "ZNN at the limit", client: restricted, block: send(allowed, types.ZnnTokenStandard, 500000000, nil)},
+		{name: "ZNN over the limit", client: restricted, block: send(allowed, types.ZnnTokenStandard, 500000001, nil), wantErr: "exceeds the maximum"},
+		{name: "token limit in base units", client: restricted, block: send(allowed, token, 1001, nil), wantErr: "exceeds the maximum"},
+		{name: "unlisted token", client: restricted, block: send(allowed, types.QsrTokenStandard, 1, nil), wantErr: "is not allowed"},
+		{name: "other destination", client: restricted, block: send(other, types.ZnnTokenStandard, 1, nil), wantErr: "destination"},
+		{name: "allowed method by signature", client: restricted, block: send(types.PillarContract, types.ZnnTokenStandard, 0, collectReward)},
+		{name: "allowed method by selector", client: restricted, block: send(types.PlasmaContract, types.ZnnTokenStandard, 0, []byte{1, 2, 3, 4, 5})},
+		{name: "method of another contract", client: restricted, block: send(types.PlasmaContract, types.ZnnTokenStandard, 0, collectReward), wantErr: "is not allowed"},
+		{name: "call without a method", client: restricted, block: send(types.PillarContract, types.ZnnTokenStandard, 0, []byte{1}), wantErr: "without a method"},
+		{name: "no rules", client: open, block: send(other, types.QsrTokenStandard, 1e15, nil)},
+		{name: "no embedded calls without methods", client: open, block: send(types.PillarContract, types.ZnnTokenStandard, 0, collectReward), wantErr: "is not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.client.checkBlock(tt.block)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignerPolicyAllow(t *testing.T) {
+	policy, err := readTestPolicy(t, `{"clients":[{"uid":1,"rateLimit":{"count":2,"interval":"1h"}},{"uid":2}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited := policy.client(1)
+	for i := 0; i < 2; i++ {
+		if err := limited.allow(); err != nil {
+			t.Fatalf("signature %d: %v", i+1, err)
+		}
+	}
+	if err := limited.allow(); err == nil {
+		t.Fatal("signature over the rate limit was allowed")
+	}
+
+	// signatures older than the interval no longer count
+	limited.signed = []time.Time{time.Now().Add(-2 * time.Hour), time.Now().Add(-61 * time.Minute)}
+	if err := limited.allow(); err != nil {
+		t.Fatal(err)
+	}
+	if len(limited.signed) != 1 {
+		t.Errorf("got %d recorded signatures, want 1", len(limited.signed))
+	}
+
+	unlimited := policy.client(2)
+	for i := 0; i < 100; i++ {
+		if err := unlimited.allow(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
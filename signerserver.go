@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	signer "github.com/ignition-pillar/go-zdk/wallet"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/wallet"
+	"golang.org/x/term"
+)
+
+const (
+	signerErrInvalidRequest = -32600
+	signerErrMethodNotFound = -32601
+	signerErrInvalidParams  = -32602
+	signerErrRefused        = -32000
+)
+
+// signerMaxRequestSize bounds the body of a signer request, far above the
+// size of any account block.
+const signerMaxRequestSize = 1 << 20
+
+type signerPeerKey struct{}
+
+// signingServer answers signer requests for a set of keyStores unlocked
+// once at startup. Without a policy every request is signed, so the socket
+// is only accessible to its owner.
+type signingServer struct {
+	keyStores map[string]*wallet.KeyStore
+	policy    *signerPolicy
+}
+
+// clientPolicy returns the policy of the client with the given uid, or nil
+// when the server has no policy.
+func (s *signingServer) clientPolicy(uid int) (*signerClientPolicy, error) {
+	if s.policy == nil {
+		return nil, nil
+	}
+	if uid < 0 {
+		return nil, errors.New("the uid of the client is unknown")
+	}
+	client := s.policy.client(uid)
+	if client == nil {
+		return nil, fmt.Errorf("uid %d is not allowed to use this signer", uid)
+	}
+	return client, nil
+}
+
+func (s *signingServer) keyPair(client *signerClientPolicy, key signerKeyParams) (signer.Signer, error) {
+	name := key.KeyStore
+	if name == "" && len(s.keyStores) == 1 {
+		for n := range s.keyStores {
+			name = n
+		}
+	}
+	ks, ok := s.keyStores[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown keyStore %q", key.KeyStore)
+	}
+	if client != nil {
+		if err := client.checkKeyStore(name); err != nil {
+			return nil, err
+		}
+	}
+	_, kp, err := ks.DeriveForIndexPath(key.Index)
+	if err != nil {
+		return nil, err
+	}
+	return signer.NewSigner(kp), nil
+}
+
+func signerErrorResponse(id int, code int, err error) signerRPCResponse {
+	return signerRPCResponse{JSONRPC: "2.0", Id: id, Error: &signerRPCError{Code: code, Message: err.Error()}}
+}
+
+func (s *signingServer) handle(client *signerClientPolicy, req signerRPCRequest) signerRPCResponse {
+	var result interface{}
+	switch req.Method {
+	case signerMethodAccount:
+		var key signerKeyParams
+		if err := json.Unmarshal(req.Params, &key); err != nil {
+			return signerErrorResponse(req.Id, signerErrInvalidParams, err)
+		}
+		kp, err := s.keyPair(client, key)
+		if err != nil {
+			return signerErrorResponse(req.Id, signerErrRefused, err)
+		}
+		result = signerAccountResult{Address: kp.Address().String(), PublicKey: hex.EncodeToString(kp.PublicKey())}
+	case signerMethodSign:
+		var params signerSignParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return signerErrorResponse(req.Id, signerErrInvalidParams, err)
+		}
+		data, err := hex.DecodeString(params.Data)
+		if err != nil {
+			return signerErrorResponse(req.Id, signerErrInvalidParams, err)
+		}
+		kp, err := s.keyPair(client, params.signerKeyParams)
+		if err != nil {
+			return signerErrorResponse(req.Id, signerErrRefused, err)
+		}
+		if client != nil {
+			if !client.AllowMessageSigning {
+				return signerErrorResponse(req.Id, signerErrRefused, errors.New("message signing is not allowed"))
+			}
+			if err := client.allow(); err != nil {
+				return signerErrorResponse(req.Id, signerErrRefused, err)
+			}
+		}
+		signature, err := kp.Sign(data)
+		if err != nil {
+			return signerErrorResponse(req.Id, signerErrRefused, err)
+		}
+		result = signerSignResult{Signature: hex.EncodeToString(signature)}
+	case signerMethodSignBlock:
+		var params signerSignBlockParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Block == nil {
+			return signerErrorResponse(req.Id, signerErrInvalidParams, errors.New("invalid account block"))
+		}
+		kp, err := s.keyPair(client, params.signerKeyParams)
+		if err != nil {
+			return signerErrorResponse(req.Id, signerErrRefused, err)
+		}
+		block := params.Block
+		if block.Address != kp.Address() {
+			return signerErrorResponse(req.Id, signerErrRefused, fmt.Errorf("block address %s does not match key %s", block.Address, kp.Address()))
+		}
+		if block.Hash != block.ComputeHash() {
+			return signerErrorResponse(req.Id, signerErrInvalidParams, errors.New("block hash does not match its contents"))
+		}
+		if client != nil {
+			if err := client.checkBlock(block); err != nil {
+				return signerErrorResponse(req.Id, signerErrRefused, err)
+			}
+			if err := client.allow(); err != nil {
+				return signerErrorResponse(req.Id, signerErrRefused, err)
+			}
+		}
+		signature, err := kp.Sign(block.Hash.Bytes())
+		if err != nil {
+			return signerErrorResponse(req.Id, signerErrRefused, err)
+		}
+		result = signerSignResult{Signature: hex.EncodeToString(signature)}
+	default:
+		return signerErrorResponse(req.Id, signerErrMethodNotFound, fmt.Errorf("unknown method %q", req.Method))
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return signerErrorResponse(req.Id, signerErrRefused, err)
+	}
+	return signerRPCResponse{JSONRPC: "2.0", Id: req.Id, Result: data}
+}
+
+// ServeHTTP answers JSON-RPC requests. Requests a browser could send, from
+// a web page through a forwarded port or a proxy, are refused.
+func (s *signingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("Origin") != "" {
+		http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	var req signerRPCRequest
+	var resp signerRPCResponse
+	uid, ok := r.Context().Value(signerPeerKey{}).(int)
+	if !ok {
+		uid = -1
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, signerMaxRequestSize)).Decode(&req); err != nil {
+		resp = signerErrorResponse(0, signerErrInvalidRequest, err)
+	} else if client, err := s.clientPolicy(uid); err != nil {
+		resp = signerErrorResponse(req.Id, signerErrRefused, err)
+	} else {
+		resp = s.handle(client, req)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// serveStdio answers a single request read from stdin, which is what the
+// exec: signer backend expects.
+func (s *signingServer) serveStdio() error {
+	line, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return err
+	}
+	var req signerRPCRequest
+	var resp signerRPCResponse
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp = signerErrorResponse(0, signerErrInvalidRequest, err)
+	} else if client, err := s.clientPolicy(os.Getuid()); err != nil {
+		resp = signerErrorResponse(req.Id, signerErrRefused, err)
+	} else {
+		resp = s.handle(client, req)
+	}
+	return json.NewEncoder(os.Stdout).Encode(resp)
+}
+
+func readKeyStore(path string, passphrase string) (*wallet.KeyStore, error) {
+	kf, err := wallet.ReadKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return kf.Decrypt(passphrase)
+}
+
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprintln(os.Stderr, prompt)
+	pw, err := term.ReadPassword(int(os.Stdin.Fd()))
+	return string(pw), err
+}
+
+var signerServeCommand = &cli.Command{
+	Name:  "serve",
+	Usage: "Serve signatures for local keyStores over a unix socket or stdio",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Usage: "unix:<socket path> to listen on, defaults to a socket in ~/.nomctl",
+		},
+		&cli.StringFlag{
+			Name:  "socket-group",
+			Usage: "Give this group access to the socket (mode 0660), requires --policy",
+		},
+		&cli.BoolFlag{
+			Name:  "stdio",
+			Usage: "Answer a single request on stdin, for use as an exec: signer",
+		},
+		&cli.StringFlag{
+			Name:  "policy",
+			Usage: "Policy file with per-client allowlists, amount limits, destinations, methods and rate limits",
+		},
+		&cli.StringSliceFlag{
+			Name:    "keyStore",
+			Aliases: []string{"k"},
+			Usage:   "Select a local keyStore to serve, can be repeated",
+		},
+		&cli.StringFlag{
+			Name:    "passphrase",
+			Aliases: []string{"p"},
+			Usage:   "use this passphrase for the keyStores or enter them manually in a secure way",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		names := cCtx.StringSlice("keyStore")
+		if len(names) == 0 {
+			files, err := os.ReadDir(walletDir)
+			if err != nil {
+				return err
+			}
+			if len(files) != 1 {
+				return errors.New("please provide a keyStore with --keyStore")
+			}
+			names = []string{files[0].Name()}
+		}
+
+		server := &signingServer{keyStores: make(map[string]*wallet.KeyStore)}
+		for _, name := range names {
+			passphrase := cCtx.String("passphrase")
+			if !cCtx.IsSet("passphrase") {
+				var err error
+				if passphrase, err = readPassphrase("Insert passphrase for " + name + ":"); err != nil {
+					return err
+				}
+			}
+			ks, err := readKeyStore(filepath.Join(walletDir, name), passphrase)
+			if err != nil {
+				return fmt.Errorf("keyStore %s: %w", name, err)
+			}
+			server.keyStores[name] = ks
+		}
+		if path := cCtx.String("policy"); path != "" {
+			policy, err := readSignerPolicy(path)
+			if err != nil {
+				return fmt.Errorf("policy: %w", err)
+			}
+			server.policy = policy
+		}
+
+		if cCtx.Bool("stdio") {
+			return server.serveStdio()
+		}
+
+		listen := cCtx.String("listen")
+		if listen == "" {
+			listen = "unix:" + filepath.Join(nomctlDir, "signer.sock")
+		}
+		if !strings.HasPrefix(listen, "unix:") {
+			return errors.New("the signer only listens on unix sockets, use --listen unix:<path>")
+		}
+		group := cCtx.String("socket-group")
+		if group != "" && server.policy == nil {
+			return errors.New("--socket-group requires a --policy for the clients of the group")
+		}
+		path := strings.TrimPrefix(listen, "unix:")
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			return err
+		}
+		if group != "" {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return err
+			}
+			gid, err := strconv.Atoi(g.Gid)
+			if err != nil {
+				return err
+			}
+			if err := os.Chown(path, -1, gid); err != nil {
+				return err
+			}
+			if err := os.Chmod(path, 0660); err != nil {
+				return err
+			}
+		}
+
+		httpServer := &http.Server{
+			Handler: server,
+			ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+				uid, err := peerUid(c)
+				if err != nil {
+					uid = -1
+					if server.policy != nil {
+						fmt.Fprintln(os.Stderr, "Refusing requests of a client with an unknown uid:", err)
+					}
+				}
+				return context.WithValue(ctx, signerPeerKey{}, uid)
+			},
+		}
+		fmt.Fprintln(os.Stderr, "Serving keyStore(s)", strings.Join(names, ", "), "on", listen)
+		return httpServer.Serve(listener)
+	},
+}
+
+var signerCommand = cli.Command{
+	Name:        "signer",
+	Usage:       "A signing service for the --signer option of znn-cli",
+	Subcommands: []*cli.Command{signerServeCommand},
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSigningServerServeHTTP(t *testing.T) {
+	policy, err := readTestPolicy(t, `{"clients":[{"uid":1000}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownMethod := `{"jsonrpc":"2.0","id":1,"method":"nomctl_unknown","params":{}}`
+
+	tests := []struct {
+		name        string
+		server      *signingServer
+		method      string
+		contentType string
+		origin      string
+		body        string
+		uid         int
+		wantStatus  int
+		wantCode    int
+	}{
+		{name: "not a post", server: &signingServer{}, method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+		{name: "text/plain", server: &signingServer{}, contentType: "text/plain", body: unknownMethod, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "no content type", server: &signingServer{}, body: unknownMethod, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "origin", server: &signingServer{}, contentType: "application/json", origin: "https://example.com", body: unknownMethod, wantStatus: http.StatusForbidden},
+		{name: "body too large", server: &signingServer{}, contentType: "application/json", body: `{"jsonrpc":"2.0","method":"` + strings.Repeat("a", signerMaxRequestSize) + `"}`, wantStatus: http.StatusOK, wantCode: signerErrInvalidRequest},
+		{name: "json", server: &signingServer{}, contentType: "application/json; charset=utf-8", body: unknownMethod, wantStatus: http.StatusOK, wantCode: signerErrMethodNotFound},
+		{name: "policy with unknown uid", server: &signingServer{policy: policy}, contentType: "application/json", body: unknownMethod, uid: -1, wantStatus: http.StatusOK, wantCode: signerErrRefused},
+		{name: "policy without the uid", server: &signingServer{policy: policy}, contentType: "application/json", body: unknownMethod, uid: 1001, wantStatus: http.StatusOK, wantCode: signerErrRefused},
+		{name: "policy with the uid", server: &signingServer{policy: policy}, contentType: "application/json", body: unknownMethod, uid: 1000, wantStatus: http.StatusOK, wantCode: signerErrMethodNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			r := httptest.NewRequest(method, "http://unix/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			r = r.WithContext(context.WithValue(r.Context(), signerPeerKey{}, tt.uid))
+			w := httptest.NewRecorder()
+			tt.server.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantCode == 0 {
+				return
+			}
+			var resp signerRPCResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error == nil || resp.Error.Code != tt.wantCode {
+				t.Errorf("got error %+v, want code %d", resp.Error, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tyler-smith/go-bip39"
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/embedded/implementation"
+	"github.com/zenon-network/go-zenon/wallet"
+)
+
+// bytesEncodingFlag selects how key and signature arguments are decoded.
+// It is not guessed, as many strings are valid hex and base64 alike.
+var bytesEncodingFlag = &cli.StringFlag{
+	Name:  "encoding",
+	Usage: "Encoding of the key and signature arguments: hex, with or without a 0x prefix, or base64",
+	Value: "hex",
+}
+
+func decodeBytes(s string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "hex":
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not hex", s)
+		}
+		return b, nil
+	case "base64":
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not base64", s)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q, expected hex or base64", encoding)
+}
+
+func decodePublicKey(s string, encoding string) (ed25519.PublicKey, error) {
+	b, err := decodeBytes(s, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes", ed25519.PublicKeySize)
+	}
+	return b, nil
+}
+
+// swapKeyId returns the key id of a legacy public key and the key id hash
+// the swap and pillar contracts store legacy entries under, derived the
+// same way as go-zenon.
+func swapKeyId(publicKey []byte) ([]byte, types.Hash, error) {
+	if len(publicKey) != 33 && len(publicKey) != 65 {
+		return nil, types.Hash{}, errors.New("legacy public key must be 33 or 65 bytes")
+	}
+	return implementation.PubKeyToKeyId(publicKey), implementation.PubKeyToKeyIdHash(publicKey), nil
+}
+
+// signerFlags select a keyStore for utils commands that sign, with the
+// same meaning as the znn-cli flags.
+var signerFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "passphrase",
+		Aliases: []string{"p"},
+		Usage:   "use this passphrase for the keyStore or enter it manually in a secure way",
+	},
+	&cli.StringFlag{
+		Name:    "keyStore",
+		Aliases: []string{"k"},
+		Usage:   "Select the local keyStore",
+	},
+	&cli.IntFlag{
+		Name:    "index",
+		Aliases: []string{"i"},
+		Usage:   "Address index",
+		Value:   0,
+	},
+	&cli.StringFlag{
+		Name:  "signer",
+		Usage: "Signer backend: keyfile, unix:<socket>, http(s)://<url> or exec:<command>",
+		Value: signerKeyFile,
+	},
+}
+
+var utilsDeriveAddress = &cli.Command{
+	Name:  "derive-address",
+	Usage: "\"mnemonic\" index",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 2 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("derive-address \"mnemonic\" index")
+			return nil
+		}
+		mnemonic := strings.Join(strings.Fields(cCtx.Args().Get(0)), " ")
+		if !bip39.IsMnemonicValid(mnemonic) {
+			return errors.New("invalid mnemonic")
+		}
+		index, err := strconv.ParseUint(cCtx.Args().Get(1), 10, 32)
+		if err != nil {
+			fmt.Println("Error! index must be a non-negative integer")
+			return err
+		}
+		entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+		if err != nil {
+			return err
+		}
+		ks := &wallet.KeyStore{
+			Entropy:  entropy,
+			Seed:     bip39.NewSeed(mnemonic, ""),
+			Mnemonic: mnemonic,
+		}
+		_, kp, err := ks.DeriveForIndexPath(uint32(index))
+		if err != nil {
+			return err
+		}
+		fmt.Println("Address:", kp.Address)
+		fmt.Println("Public key:", hex.EncodeToString(kp.Public))
+		return nil
+	},
+}
+
+var utilsPubKeyToAddress = &cli.Command{
+	Name:  "pubkey-to-address",
+	Usage: "publicKey",
+	Flags: []cli.Flag{bytesEncodingFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("pubkey-to-address publicKey")
+			return nil
+		}
+		publicKey, err := decodePublicKey(cCtx.Args().Get(0), cCtx.String(bytesEncodingFlag.Name))
+		if err != nil {
+			return err
+		}
+		fmt.Println(types.PubKeyToAddress(publicKey))
+		return nil
+	},
+}
+
+// messageSigningDomain separates message signatures from block
+// signatures made with the same key: the signed digest is the hash of the
+// domain followed by the message, never a hash a block could have.
+const messageSigningDomain = "nomctl signed message:\n"
+
+func messageDigest(message string) []byte {
+	return types.NewHash(append([]byte(messageSigningDomain), message...)).Bytes()
+}
+
+func verifyMessage(publicKey ed25519.PublicKey, message string, signature []byte) bool {
+	return ed25519.Verify(publicKey, messageDigest(message), signature)
+}
+
+var utilsSignMessage = &cli.Command{
+	Name:  "sign-message",
+	Usage: "message",
+	Flags: signerFlags,
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("sign-message message")
+			return nil
+		}
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+		message := cCtx.Args().Get(0)
+		signature, err := kp.Sign(messageDigest(message))
+		if err != nil {
+			fmt.Println("Error signing message:", err)
+			return err
+		}
+		fmt.Println("Address:", kp.Address())
+		fmt.Println("Public key:", hex.EncodeToString(kp.PublicKey()))
+		fmt.Println("Signature:", hex.EncodeToString(signature))
+		return nil
+	},
+}
+
+var utilsVerifyMessage = &cli.Command{
+	Name:  "verify-message",
+	Usage: "address publicKey signature message",
+	Flags: []cli.Flag{bytesEncodingFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 4 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("verify-message address publicKey signature message")
+			return nil
+		}
+		address, err := resolveAddress(cCtx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+		publicKey, err := decodePublicKey(cCtx.Args().Get(1), cCtx.String(bytesEncodingFlag.Name))
+		if err != nil {
+			return err
+		}
+		signature, err := decodeBytes(cCtx.Args().Get(2), cCtx.String(bytesEncodingFlag.Name))
+		if err != nil {
+			return err
+		}
+		if types.PubKeyToAddress(publicKey) != address {
+			return fmt.Errorf("public key does not belong to %s", address)
+		}
+		if !verifyMessage(publicKey, cCtx.Args().Get(3), signature) {
+			return errors.New("invalid signature")
+		}
+		fmt.Println("Valid signature by", address)
+		return nil
+	},
+}
+
+var utilsAddressInfo = &cli.Command{
+	Name:  "address-info",
+	Usage: "address",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("address-info address")
+			return nil
+		}
+		address, err := resolveAddress(cCtx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+		fmt.Println("Address:", address)
+		fmt.Println("Bytes:", hex.EncodeToString(address.Bytes()))
+		if types.IsEmbeddedAddress(address) {
+			name, ok := embeddedContractName(address)
+			if !ok {
+				name = "unknown"
+			}
+			fmt.Println("Type: embedded contract", "("+name+")")
+		} else {
+			fmt.Println("Type: user address")
+		}
+		return nil
+	},
+}
+
+var utilsHash = &cli.Command{
+	Name:  "hash",
+	Usage: "data",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "algo",
+			Usage: "Hash algorithm: sha3 (sha3-256, used for NoM hashes) or sha256",
+			Value: "sha3",
+		},
+		&cli.BoolFlag{
+			Name:  "hex",
+			Usage: "Treat data as hex encoded bytes instead of text",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("hash data")
+			return nil
+		}
+		data := []byte(cCtx.Args().Get(0))
+		if cCtx.Bool("hex") {
+			var err error
+			data, err = hex.DecodeString(strings.TrimPrefix(string(data), "0x"))
+			if err != nil {
+				return err
+			}
+		}
+		switch cCtx.String("algo") {
+		case "sha3":
+			fmt.Println(types.NewHash(data))
+		case "sha256":
+			sum := sha256.Sum256(data)
+			fmt.Println(hex.EncodeToString(sum[:]))
+		default:
+			return fmt.Errorf("unknown hash algorithm %q, expected sha3 or sha256", cCtx.String("algo"))
+		}
+		return nil
+	},
+}
+
+var utilsZtsInfo = &cli.Command{
+	Name:  "zts-info",
+	Usage: "zts|hexBytes",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("zts-info zts|hexBytes")
+			return nil
+		}
+		arg := cCtx.Args().Get(0)
+		zts, err := types.ParseZTS(arg)
+		if err != nil {
+			b, hexErr := hex.DecodeString(strings.TrimPrefix(arg, "0x"))
+			if hexErr != nil {
+				return fmt.Errorf("%s is not a valid token standard: %w", arg, err)
+			}
+			if zts, err = types.BytesToZTS(b); err != nil {
+				return err
+			}
+		}
+		fmt.Println("Token standard:", zts)
+		fmt.Println("Bytes:", hex.EncodeToString(zts.Bytes()))
+		switch zts {
+		case types.ZnnTokenStandard:
+			fmt.Println("Token: ZNN")
+		case types.QsrTokenStandard:
+			fmt.Println("Token: QSR")
+		}
+		return nil
+	},
+}
+
+var utilsAddressBytes = &cli.Command{
+	Name:  "address-bytes",
+	Usage: "address",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("address-bytes address")
+			return nil
+		}
+		address, err := resolveAddress(cCtx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+		fmt.Println(hex.EncodeToString(address.Bytes()))
+		return nil
+	},
+}
+
+var utilsBytesToAddress = &cli.Command{
+	Name:  "bytes-to-address",
+	Usage: "hexBytes",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("bytes-to-address hexBytes")
+			return nil
+		}
+		b, err := hex.DecodeString(strings.TrimPrefix(cCtx.Args().Get(0), "0x"))
+		if err != nil {
+			return err
+		}
+		address, err := types.BytesToAddress(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println(address)
+		return nil
+	},
+}
+
+// encodingCommand builds a converter between text and an encoding.
+func encodingCommand(name string, encode func([]byte) string, decode func(string) ([]byte, error)) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: "encode|decode data",
+		Action: func(cCtx *cli.Context) error {
+			if cCtx.NArg() != 2 {
+				fmt.Println("Incorrect number of arguments. Expected:")
+				fmt.Println(name, "encode|decode data")
+				return nil
+			}
+			data := cCtx.Args().Get(1)
+			switch cCtx.Args().Get(0) {
+			case "encode":
+				fmt.Println(encode([]byte(data)))
+			case "decode":
+				b, err := decode(data)
+				if err != nil {
+					return err
+				}
+				if utf8.Valid(b) {
+					fmt.Println(string(b))
+				} else {
+					fmt.Println("Decoded bytes are not valid text, hex:", hex.EncodeToString(b))
+				}
+			default:
+				return fmt.Errorf("unknown operation %q, expected encode or decode", cCtx.Args().Get(0))
+			}
+			return nil
+		},
+	}
+}
+
+var utilsHex = encodingCommand("hex", hex.EncodeToString, func(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+})
+
+var utilsBase64 = encodingCommand("base64", base64.StdEncoding.EncodeToString, base64.StdEncoding.DecodeString)
+
+var utilsSwapKeyId = &cli.Command{
+	Name:  "swap-keyid",
+	Usage: "legacyPublicKey",
+	Flags: []cli.Flag{bytesEncodingFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("swap-keyid legacyPublicKey")
+			return nil
+		}
+		publicKey, err := decodeBytes(cCtx.Args().Get(0), cCtx.String(bytesEncodingFlag.Name))
+		if err != nil {
+			return err
+		}
+		keyId, keyIdHash, err := swapKeyId(publicKey)
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+		fmt.Println("Key id:", hex.EncodeToString(keyId))
+		fmt.Println("Key id hash:", keyIdHash)
+		return nil
+	},
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+func TestDecodeBytes(t *testing.T) {
+	// "deadbeef" is valid hex and valid base64, the encoding decides
+	hexBytes, _ := hex.DecodeString("deadbeef")
+	base64Bytes, _ := base64.StdEncoding.DecodeString("deadbeef")
+	tests := []struct {
+		s        string
+		encoding string
+		want     []byte
+	}{
+		{"deadbeef", "hex", hexBytes},
+		{"0xdeadbeef", "hex", hexBytes},
+		{"deadbeef", "base64", base64Bytes},
+	}
+	for _, tt := range tests {
+		got, err := decodeBytes(tt.s, tt.encoding)
+		if err != nil {
+			t.Errorf("decodeBytes(%q, %s): %v", tt.s, tt.encoding, err)
+		} else if !bytes.Equal(got, tt.want) {
+			t.Errorf("decodeBytes(%q, %s) = %x, want %x", tt.s, tt.encoding, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ s, encoding string }{
+		{"3q2+7w==", "hex"},
+		{"0xdeadbeef", "base64"},
+		{"deadbeef", "base58"},
+	} {
+		if _, err := decodeBytes(tt.s, tt.encoding); err == nil {
+			t.Errorf("decodeBytes(%q, %s) succeeded", tt.s, tt.encoding)
+		}
+	}
+}
+
+func TestVerifyMessage(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	key := ed25519.NewKeyFromSeed(seed)
+	publicKey := key.Public().(ed25519.PublicKey)
+	signature := ed25519.Sign(key, messageDigest("hello"))
+
+	if !verifyMessage(publicKey, "hello", signature) {
+		t.Fatal("valid signature rejected")
+	}
+	if verifyMessage(publicKey, "hello!", signature) {
+		t.Error("signature accepted for another message")
+	}
+	if verifyMessage(publicKey, "hello", ed25519.Sign(key, []byte("hello"))) {
+		t.Error("signature over the raw message accepted")
+	}
+}
+
+func TestSwapKeyId(t *testing.T) {
+	// the public key of private key 1 and its well known hash160
+	publicKey, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	keyId, keyIdHash, err := swapKeyId(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(keyId); got != "751e76e8199196d454941c45d1b3a323f1433bd6" {
+		t.Errorf("got key id %s", got)
+	}
+	if keyIdHash != types.NewHash(keyId) {
+		t.Errorf("got key id hash %v, want the hash of the key id", keyIdHash)
+	}
+
+	for _, n := range []int{0, 32, 64, 66} {
+		if _, _, err := swapKeyId(make([]byte, n)); err == nil {
+			t.Errorf("%d byte public key was accepted", n)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/genesis"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+var devnetToolsCommand = cli.Command{
+	Name:        "devnet",
+	Usage:       "Devnet tools",
+	Category:    "DEVELOPER COMMANDS",
+	Subcommands: []*cli.Command{devnetValidateGenesisCommand},
+}
+
+var devnetValidateGenesisCommand = &cli.Command{
+	Name:      "validate-genesis",
+	Usage:     "Checks a genesis.json and prints its genesis momentum hash",
+	ArgsUsage: "path",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("devnet validate-genesis path")
+			return nil
+		}
+		gen, err := readGenesisConfig(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error reading genesis:", err)
+			return err
+		}
+
+		problems := checkGenesis(gen)
+		if len(problems) > 0 {
+			fmt.Println("The genesis is invalid:")
+			for _, problem := range problems {
+				fmt.Println("  " + problem)
+			}
+			return errors.New("invalid genesis")
+		}
+
+		fmt.Println("Chain identifier:", gen.ChainIdentifier)
+		fmt.Println("Genesis timestamp:", gen.GenesisTimestampSec)
+		for _, token := range gen.TokenConfig.Tokens {
+			fmt.Println(token.TokenSymbol, "total supply:", formatAmount(token.TotalSupply, token.Decimals), "of", formatAmount(token.MaxSupply, token.Decimals))
+		}
+		fmt.Println("Pillars:", len(gen.PillarConfig.Pillars))
+		fmt.Println("Sporks:", len(gen.SporkConfig.Sporks))
+		fmt.Println("Genesis momentum hash:", genesis.NewGenesis(gen).GetGenesisMomentum().Hash)
+		return nil
+	},
+}
+
+func readGenesisConfig(path string) (*genesis.GenesisConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	gen := &genesis.GenesisConfig{}
+	if err := json.Unmarshal(data, gen); err != nil {
+		return nil, err
+	}
+	return gen, nil
+}
+
+// checkGenesis returns every inconsistency found in gen. Embedded contract
+// balances must match the state they back and total supplies the sum of all
+// genesis balances.
+func checkGenesis(gen *genesis.GenesisConfig) []string {
+	var problems []string
+	problem := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	if gen.ChainIdentifier == 0 {
+		problem("chain identifier is 0")
+	}
+	if gen.PillarConfig == nil || gen.TokenConfig == nil || gen.PlasmaConfig == nil || gen.SwapConfig == nil || gen.SporkConfig == nil || gen.GenesisBlocks == nil {
+		problem("genesis is missing contract configs")
+		return problems
+	}
+
+	// genesis blocks
+	balances := make(map[types.Address]map[types.ZenonTokenStandard]*big.Int)
+	totals := make(map[types.ZenonTokenStandard]*big.Int)
+	for _, block := range gen.GenesisBlocks.Blocks {
+		if _, ok := balances[block.Address]; ok {
+			problem("genesis block for %v is set more than once", block.Address)
+			continue
+		}
+		balances[block.Address] = block.BalanceList
+		for zts, amount := range block.BalanceList {
+			if amount == nil || amount.Sign() < 0 {
+				problem("genesis block for %v has an invalid %v balance", block.Address, zts)
+				continue
+			}
+			if _, ok := totals[zts]; !ok {
+				totals[zts] = big.NewInt(0)
+			}
+			totals[zts].Add(totals[zts], amount)
+		}
+	}
+	balance := func(address types.Address, zts types.ZenonTokenStandard) *big.Int {
+		if amount, ok := balances[address][zts]; ok && amount != nil {
+			return amount
+		}
+		return big.NewInt(0)
+	}
+
+	// tokens
+	tokens := make(map[types.ZenonTokenStandard]bool)
+	for _, token := range gen.TokenConfig.Tokens {
+		if tokens[token.TokenStandard] {
+			problem("token %v is set more than once", token.TokenStandard)
+			continue
+		}
+		tokens[token.TokenStandard] = true
+		if token.TotalSupply == nil || token.MaxSupply == nil {
+			problem("token %s has no total or max supply", token.TokenSymbol)
+			continue
+		}
+		total := totals[token.TokenStandard]
+		if total == nil {
+			total = big.NewInt(0)
+		}
+		if token.TotalSupply.Cmp(total) != 0 {
+			problem("%s total supply %s does not match the genesis balances %s", token.TokenSymbol, formatAmount(token.TotalSupply, token.Decimals), formatAmount(total, token.Decimals))
+		}
+		if token.TotalSupply.Cmp(token.MaxSupply) > 0 {
+			problem("%s total supply %s exceeds its max supply %s", token.TokenSymbol, formatAmount(token.TotalSupply, token.Decimals), formatAmount(token.MaxSupply, token.Decimals))
+		}
+	}
+	for _, zts := range []types.ZenonTokenStandard{types.ZnnTokenStandard, types.QsrTokenStandard} {
+		if !tokens[zts] {
+			problem("token %v is missing", zts)
+		}
+	}
+	for zts := range totals {
+		if !tokens[zts] {
+			problem("genesis balances of %v have no token", zts)
+		}
+	}
+
+	// pillars and delegations
+	pillarNames := make(map[string]bool)
+	producers := make(map[types.Address]bool)
+	pillarsZnn := big.NewInt(0)
+	for _, pillar := range gen.PillarConfig.Pillars {
+		if !pillarNameRegexp.MatchString(pillar.Name) || len(pillar.Name) > pillarNameMaxLength {
+			problem("pillar name %q is invalid", pillar.Name)
+		}
+		if pillarNames[pillar.Name] {
+			problem("pillar name %q is used more than once", pillar.Name)
+		}
+		if producers[pillar.BlockProducingAddress] {
+			problem("pillar producer %v is used more than once", pillar.BlockProducingAddress)
+		}
+		pillarNames[pillar.Name] = true
+		producers[pillar.BlockProducingAddress] = true
+		if pillar.Amount == nil {
+			problem("pillar %q has no amount", pillar.Name)
+			continue
+		}
+		pillarsZnn.Add(pillarsZnn, pillar.Amount)
+	}
+	if len(gen.PillarConfig.Pillars) == 0 {
+		problem("genesis has no pillars, no momentums can be produced")
+	}
+	if b := balance(types.PillarContract, types.ZnnTokenStandard); b.Cmp(pillarsZnn) != 0 {
+		problem("pillar contract ZNN balance %s does not match the pillar amounts %s", formatAmount(b, ZnnDecimals), formatAmount(pillarsZnn, ZnnDecimals))
+	}
+	legacyKeyIdHashes := make(map[types.Hash]bool)
+	for _, entry := range gen.PillarConfig.LegacyEntries {
+		if legacyKeyIdHashes[entry.KeyIdHash] {
+			problem("legacy pillar key id hash %v is used more than once", entry.KeyIdHash)
+		}
+		legacyKeyIdHashes[entry.KeyIdHash] = true
+		if entry.PillarCount == 0 {
+			problem("legacy pillar entry %v has a pillar count of 0", entry.KeyIdHash)
+		}
+	}
+	backers := make(map[types.Address]bool)
+	for _, delegation := range gen.PillarConfig.Delegations {
+		if !pillarNames[delegation.Name] {
+			problem("delegation of %v is to unknown pillar %q", delegation.Backer, delegation.Name)
+		}
+		if backers[delegation.Backer] {
+			problem("%v delegates more than once", delegation.Backer)
+		}
+		backers[delegation.Backer] = true
+	}
+
+	// fusions
+	fusionIds := make(map[types.Hash]bool)
+	fusedQsr := big.NewInt(0)
+	for _, fusion := range gen.PlasmaConfig.Fusions {
+		if fusionIds[fusion.Id] {
+			problem("fusion id %v is used more than once", fusion.Id)
+		}
+		fusionIds[fusion.Id] = true
+		if fusion.Amount == nil || fusion.Amount.Sign() <= 0 {
+			problem("fusion %v has an invalid amount", fusion.Id)
+			continue
+		}
+		fusedQsr.Add(fusedQsr, fusion.Amount)
+	}
+	if b := balance(types.PlasmaContract, types.QsrTokenStandard); b.Cmp(fusedQsr) != 0 {
+		problem("plasma contract QSR balance %s does not match the fusions %s", formatAmount(b, QsrDecimals), formatAmount(fusedQsr, QsrDecimals))
+	}
+
+	// swap entries
+	keyIdHashes := make(map[types.Hash]bool)
+	swapZnn, swapQsr := big.NewInt(0), big.NewInt(0)
+	for _, entry := range gen.SwapConfig.Entries {
+		if keyIdHashes[entry.KeyIdHash] {
+			problem("swap key id hash %v is used more than once", entry.KeyIdHash)
+		}
+		keyIdHashes[entry.KeyIdHash] = true
+		if entry.Znn != nil {
+			swapZnn.Add(swapZnn, entry.Znn)
+		}
+		if entry.Qsr != nil {
+			swapQsr.Add(swapQsr, entry.Qsr)
+		}
+	}
+	if b := balance(types.SwapContract, types.ZnnTokenStandard); b.Cmp(swapZnn) != 0 {
+		problem("swap contract ZNN balance %s does not match the swap entries %s", formatAmount(b, ZnnDecimals), formatAmount(swapZnn, ZnnDecimals))
+	}
+	if b := balance(types.SwapContract, types.QsrTokenStandard); b.Cmp(swapQsr) != 0 {
+		problem("swap contract QSR balance %s does not match the swap entries %s", formatAmount(b, QsrDecimals), formatAmount(swapQsr, QsrDecimals))
+	}
+
+	// sporks
+	if gen.SporkAddress == nil {
+		problem("spork address is not set")
+	}
+	sporkIds := make(map[types.Hash]bool)
+	for _, spork := range gen.SporkConfig.Sporks {
+		if sporkIds[spork.Id] {
+			problem("spork %v is set more than once", spork.Id)
+		}
+		sporkIds[spork.Id] = true
+		if !spork.Activated && spork.EnforcementHeight != 0 {
+			problem("spork %v is not activated but has an enforcement height", spork.Id)
+		}
+	}
+
+	return problems
+}
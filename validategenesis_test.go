@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/zenon-network/go-zenon/chain/genesis"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+func TestCheckGenesis(t *testing.T) {
+	tests := []struct {
+		name        string
+		edit        func(*genesis.GenesisConfig)
+		wantProblem string
+	}{
+		{name: "valid", edit: func(*genesis.GenesisConfig) {}},
+		{name: "chain id 0", edit: func(gen *genesis.GenesisConfig) { gen.ChainIdentifier = 0 }, wantProblem: "chain identifier is 0"},
+		{name: "missing config", edit: func(gen *genesis.GenesisConfig) { gen.SwapConfig = nil }, wantProblem: "missing contract configs"},
+		{name: "duplicate genesis block", edit: func(gen *genesis.GenesisConfig) {
+			gen.GenesisBlocks.Blocks = append(gen.GenesisBlocks.Blocks, gen.GenesisBlocks.Blocks[0])
+		}, wantProblem: "set more than once"},
+		{name: "total supply mismatch", edit: func(gen *genesis.GenesisConfig) {
+			gen.TokenConfig.Tokens[0].TotalSupply = big.NewInt(1)
+		}, wantProblem: "does not match the genesis balances"},
+		{name: "total over max supply", edit: func(gen *genesis.GenesisConfig) {
+			gen.TokenConfig.Tokens[0].MaxSupply = big.NewInt(1)
+		}, wantProblem: "exceeds its max supply"},
+		{name: "missing token", edit: func(gen *genesis.GenesisConfig) {
+			gen.TokenConfig.Tokens = gen.TokenConfig.Tokens[:1]
+		}, wantProblem: "is missing"},
+		{name: "no pillars", edit: func(gen *genesis.GenesisConfig) {
+			gen.PillarConfig.Pillars = nil
+		}, wantProblem: "no pillars"},
+		{name: "pillar contract balance mismatch", edit: func(gen *genesis.GenesisConfig) {
+			gen.PillarConfig.Pillars[0].Amount = big.NewInt(1)
+		}, wantProblem: "pillar contract ZNN balance"},
+		{name: "invalid pillar name", edit: func(gen *genesis.GenesisConfig) {
+			gen.PillarConfig.Pillars[0].Name = "no spaces"
+		}, wantProblem: "is invalid"},
+		{name: "delegation to unknown pillar", edit: func(gen *genesis.GenesisConfig) {
+			gen.PillarConfig.Delegations = []*definition.DelegationInfo{{Backer: testAddress(2), Name: "Nobody"}}
+		}, wantProblem: "unknown pillar"},
+		{name: "duplicate legacy pillar entry", edit: func(gen *genesis.GenesisConfig) {
+			entry := &definition.LegacyPillarEntry{KeyIdHash: types.NewHash([]byte("key")), PillarCount: 1}
+			gen.PillarConfig.LegacyEntries = []*definition.LegacyPillarEntry{entry, entry}
+		}, wantProblem: "legacy pillar key id hash"},
+		{name: "legacy pillar entry without pillars", edit: func(gen *genesis.GenesisConfig) {
+			gen.PillarConfig.LegacyEntries = []*definition.LegacyPillarEntry{{KeyIdHash: types.NewHash([]byte("key"))}}
+		}, wantProblem: "pillar count of 0"},
+		{name: "fusions without plasma balance", edit: func(gen *genesis.GenesisConfig) {
+			gen.PlasmaConfig.Fusions = []*definition.FusionInfo{{Id: types.NewHash([]byte("fusion")), Amount: big.NewInt(1)}}
+		}, wantProblem: "plasma contract QSR balance"},
+		{name: "duplicate swap entry", edit: func(gen *genesis.GenesisConfig) {
+			entry := &definition.SwapAssets{KeyIdHash: types.NewHash([]byte("key")), Znn: big.NewInt(0), Qsr: big.NewInt(0)}
+			gen.SwapConfig.Entries = []*definition.SwapAssets{entry, entry}
+		}, wantProblem: "swap key id hash"},
+		{name: "swap entries without balance", edit: func(gen *genesis.GenesisConfig) {
+			gen.SwapConfig.Entries = []*definition.SwapAssets{{KeyIdHash: types.NewHash([]byte("key")), Znn: big.NewInt(1), Qsr: big.NewInt(0)}}
+		}, wantProblem: "swap contract ZNN balance"},
+		{name: "no spork address", edit: func(gen *genesis.GenesisConfig) { gen.SporkAddress = nil }, wantProblem: "spork address"},
+		{name: "inactive spork with enforcement height", edit: func(gen *genesis.GenesisConfig) {
+			gen.SporkConfig.Sporks[0].Activated = false
+			gen.SporkConfig.Sporks[0].EnforcementHeight = 5
+		}, wantProblem: "not activated"},
+		{name: "duplicate spork", edit: func(gen *genesis.GenesisConfig) {
+			gen.SporkConfig.Sporks = append(gen.SporkConfig.Sporks, gen.SporkConfig.Sporks[0])
+		}, wantProblem: "is set more than once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := createDevGenesis(defaultGenesisTemplate(), []types.Address{testAddress(1)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(gen)
+			problems := checkGenesis(gen)
+			if tt.wantProblem == "" {
+				if len(problems) != 0 {
+					t.Fatalf("got problems %v", problems)
+				}
+				return
+			}
+			for _, problem := range problems {
+				if strings.Contains(problem, tt.wantProblem) {
+					return
+				}
+			}
+			t.Errorf("got problems %v, want one containing %q", problems, tt.wantProblem)
+		})
+	}
+}
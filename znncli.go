@@ -1,24 +1,44 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/ignition-pillar/go-zdk/utils"
 	"github.com/ignition-pillar/go-zdk/utils/template"
 	signer "github.com/ignition-pillar/go-zdk/wallet"
+	"github.com/ignition-pillar/go-zdk/zdk"
 	"github.com/tyler-smith/go-bip39"
 	"github.com/urfave/cli/v2"
+	"github.com/zenon-network/go-zenon/chain/nom"
 	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/rpc/api"
+	"github.com/zenon-network/go-zenon/rpc/server"
+	"github.com/zenon-network/go-zenon/vm/abi"
 	"github.com/zenon-network/go-zenon/vm/constants"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
 	"github.com/zenon-network/go-zenon/wallet"
 	"golang.org/x/term"
 )
 
 func getZnnCliSigner(walletDir string, cCtx *cli.Context) (signer.Signer, error) {
 
+	if uri := cCtx.String("signer"); uri != "" && uri != signerKeyFile {
+		return newRemoteSigner(uri, cCtx.String("keyStore"), uint32(cCtx.Int("index")))
+	}
+
 	var keyStorePath string
 
 	// TODO use go-zdk keystore manager when available
@@ -124,6 +144,77 @@ var znnCliUnreceived = &cli.Command{
 	},
 }
 
+// swap.retrieve sends the swap assets of a legacy key to the address of the
+// selected keyStore. The signature is made by the legacy secp256k1 key over
+// the message the swap contract checks for that address, see
+// vm/embedded/implementation/swap.go in go-zenon. nomctl holds no legacy
+// keys, so it has to be produced with the legacy wallet.
+var znnCliSwapRetrieve = &cli.Command{
+	Name:  "swap.retrieve",
+	Usage: "legacyPublicKey signature",
+	Flags: []cli.Flag{bytesEncodingFlag},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 2 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("swap.retrieve legacyPublicKey signature")
+			return nil
+		}
+		publicKey, err := decodeBytes(cCtx.Args().Get(0), cCtx.String(bytesEncodingFlag.Name))
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+		_, keyIdHash, err := swapKeyId(publicKey)
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+		signature, err := decodeBytes(cCtx.Args().Get(1), cCtx.String(bytesEncodingFlag.Name))
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+
+		assets, err := z.Embedded.Swap.GetAssetsByKeyIdHash(keyIdHash)
+		if err != nil {
+			fmt.Println("Error getting swap assets of key id hash", keyIdHash.String()+":", err)
+			return err
+		}
+		if assets == nil || assets.Znn == nil || assets.Qsr == nil || (assets.Znn.Sign() == 0 && assets.Qsr.Sign() == 0) {
+			fmt.Println("No assets left to retrieve for key id hash", keyIdHash)
+			return nil
+		}
+		fmt.Println("Retrieving", formatAmount(assets.Znn, ZnnDecimals), "ZNN and", formatAmount(assets.Qsr, QsrDecimals), "QSR to", kp.Address(), "...")
+
+		// the swap contract expects both in base64
+		template, err := z.Embedded.Swap.RetrieveAssets(base64.StdEncoding.EncodeToString(publicKey), base64.StdEncoding.EncodeToString(signature))
+		if err != nil {
+			fmt.Println("Error templating swap retrieve tx:", err)
+			return err
+		}
+		_, err = sendBlock(cCtx, z, template, kp)
+		if err != nil {
+			fmt.Println("Error sending swap retrieve tx:", err)
+			return err
+		}
+
+		fmt.Println("Done")
+		fmt.Println("Use 'receiveAll' to receive the retrieved assets after 1 momentum")
+		return nil
+	},
+}
+
 var znnCliReceiveAll = &cli.Command{
 	Name:  "receiveAll",
 	Usage: "",
@@ -165,7 +256,11 @@ var znnCliReceiveAll = &cli.Command{
 		for unreceived.Count > 0 {
 			for _, block := range unreceived.List {
 				temp := template.Receive(1, uint64(chainId), block.Hash)
-				_, err = utils.Send(z, temp, kp, false)
+				_, err = sendBlock(cCtx, z, temp, kp)
+				if err != nil {
+					fmt.Println("Error receiving "+block.Hash.String()+":", err)
+					return err
+				}
 			}
 			unreceived, err = z.Ledger.GetUnreceivedBlocksByAddress(kp.Address(), 0, 5)
 			if err != nil {
@@ -179,6 +274,75 @@ var znnCliReceiveAll = &cli.Command{
 	},
 }
 
+var znnCliSend = &cli.Command{
+	Name:  "send",
+	Usage: "toAddress|@contact amount [ZNN|QSR|ZTS]",
+	Action: func(cCtx *cli.Context) error {
+		if !(cCtx.NArg() == 2 || cCtx.NArg() == 3) {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("send toAddress|@contact amount [ZNN|QSR|ZTS]")
+			return nil
+		}
+		toAddress, err := resolveAddress(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error! Invalid address:", err)
+			return err
+		}
+		zts := types.ZnnTokenStandard
+		if cCtx.NArg() == 3 {
+			zts, err = parseTokenStandard(cCtx.Args().Get(2))
+			if err != nil {
+				fmt.Println("Error! Invalid token standard:", err)
+				return err
+			}
+		}
+
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+		info, err := z.Ledger.GetAccountInfoByAddress(kp.Address())
+		if err != nil {
+			fmt.Println("Error getting account info:", err)
+			return err
+		}
+		balance, ok := info.BalanceInfoMap[zts]
+		if !ok {
+			fmt.Println("Error! You do not have any", zts.String(), "tokens")
+			return nil
+		}
+		amount, err := parseAmount(cCtx.Args().Get(1), balance.TokenInfo.Decimals)
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+		if amount.Sign() <= 0 {
+			fmt.Println("Error! Amount must be greater than 0")
+			return nil
+		}
+		if balance.Balance.Cmp(amount) < 0 {
+			fmt.Println("Error! You only have", formatAmount(balance.Balance, balance.TokenInfo.Decimals), balance.TokenInfo.TokenSymbol)
+			return nil
+		}
+
+		fmt.Println("Sending", formatAmount(amount, balance.TokenInfo.Decimals), balance.TokenInfo.TokenSymbol, "to", toAddress)
+		_, err = sendBlock(cCtx, z, sendTemplate(toAddress, zts, amount), kp)
+		if err != nil {
+			fmt.Println("Error sending tx:", err)
+			return err
+		}
+
+		fmt.Println("Done")
+		return nil
+	},
+}
+
 var znnCliBalance = &cli.Command{
 	Name: "balance",
 	Action: func(cCtx *cli.Context) error {
@@ -238,6 +402,458 @@ var znnCliFrontierMomentum = &cli.Command{
 	},
 }
 
+func printMomentum(m *api.Momentum) {
+	fmt.Println("Momentum height:", m.Height)
+	fmt.Println("  Hash:", m.Hash.String())
+	fmt.Println("  PreviousHash:", m.PreviousHash.String())
+	fmt.Println("  Producer:", m.Producer.String())
+	fmt.Println("  Timestamp:", m.TimestampUnix, time.Unix(int64(m.TimestampUnix), 0).UTC().Format(time.RFC3339))
+	fmt.Println("  ChangesHash:", m.ChangesHash.String())
+	if len(m.Content) == 0 {
+		fmt.Println("  Content: empty")
+		return
+	}
+	fmt.Println("  Content:", len(m.Content), "account block(s)")
+	for _, header := range m.Content {
+		fmt.Printf("    %s height %d hash %s\n", header.Address, header.Height, header.Hash)
+	}
+}
+
+func printDetailedMomentum(dm *api.DetailedMomentum) {
+	printMomentum(dm.Momentum)
+	for _, block := range dm.AccountBlocks {
+		fmt.Printf("    Block %s type %d from %s to %s\n", block.Hash, block.BlockType, block.Address, block.ToAddress)
+		if block.TokenInfo != nil && block.Amount != nil && block.Amount.Sign() != 0 {
+			fmt.Printf("      Amount %s %s\n", formatAmount(block.Amount, block.TokenInfo.Decimals), block.TokenInfo.TokenSymbol)
+		}
+	}
+}
+
+// printMomentums prints the momentums from height up to and including to
+// and returns the next height to print.
+func printMomentums(z *zdk.Zdk, height, to uint64, detailed bool) (uint64, error) {
+	for height <= to {
+		count := to - height + 1
+		if count > rpcMaxPageSize {
+			count = rpcMaxPageSize
+		}
+		if detailed {
+			list, err := z.Ledger.GetDetailedMomentumsByHeight(height, count)
+			if err != nil {
+				return height, err
+			}
+			for _, dm := range list.List {
+				printDetailedMomentum(dm)
+			}
+		} else {
+			list, err := z.Ledger.GetMomentumsByHeight(height, count)
+			if err != nil {
+				return height, err
+			}
+			for _, m := range list.List {
+				printMomentum(m)
+			}
+		}
+		height += count
+	}
+	return height, nil
+}
+
+// momentumEvent is an entry of a ledger momentums subscription event.
+type momentumEvent struct {
+	Hash   types.Hash `json:"hash"`
+	Height uint64     `json:"height"`
+}
+
+// watchMomentums subscribes to new momentums and prints every momentum
+// from height onwards as it is produced, including the ones produced
+// before the subscription started. It only returns on error.
+func watchMomentums(z *zdk.Zdk, height uint64, detailed bool) error {
+	rpc, err := server.Dial(url)
+	if err != nil {
+		return err
+	}
+	defer rpc.Close()
+	events := make(chan []momentumEvent)
+	sub, err := rpc.Subscribe(context.Background(), "ledger", events, "momentums")
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	frontier, err := z.Ledger.GetFrontierMomentum()
+	if err != nil {
+		return err
+	}
+	if height, err = printMomentums(z, height, frontier.Height, detailed); err != nil {
+		return err
+	}
+	for {
+		select {
+		case err := <-sub.Err():
+			return err
+		case event := <-events:
+			for _, m := range event {
+				if m.Height < height {
+					continue
+				}
+				if height, err = printMomentums(z, height, m.Height, detailed); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+var momentumWatchFlag = &cli.BoolFlag{
+	Name:  "watch",
+	Usage: "Keep printing the momentums that follow as they are produced",
+}
+
+var znnCliMomentumGet = &cli.Command{
+	Name:  "momentum.get",
+	Usage: "height|hash",
+	Flags: []cli.Flag{
+		momentumWatchFlag,
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("momentum.get height|hash")
+			return nil
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+
+		arg := cCtx.Args().Get(0)
+		var m *api.Momentum
+		if height, err := strconv.ParseUint(arg, 10, 64); err == nil {
+			list, err := z.Ledger.GetMomentumsByHeight(height, 1)
+			if err != nil {
+				fmt.Println("Error getting momentum:", err)
+				return err
+			}
+			if len(list.List) == 0 {
+				fmt.Println("No momentum found at height", height)
+				return nil
+			}
+			m = list.List[0]
+		} else {
+			hash, err := types.HexToHash(arg)
+			if err != nil {
+				fmt.Println("Error! Expected a momentum height or hash")
+				return err
+			}
+			m, err = z.Ledger.GetMomentumByHash(hash)
+			if err != nil {
+				fmt.Println("Error getting momentum:", err)
+				return err
+			}
+			if m == nil {
+				fmt.Println("No momentum found with hash", hash)
+				return nil
+			}
+		}
+		printMomentum(m)
+
+		if cCtx.Bool(momentumWatchFlag.Name) {
+			return watchMomentums(z, m.Height+1, false)
+		}
+		return nil
+	},
+}
+
+var znnCliMomentumRange = &cli.Command{
+	Name:  "momentum.range",
+	Usage: "from count",
+	Flags: []cli.Flag{
+		momentumWatchFlag,
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 2 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("momentum.range from count")
+			return nil
+		}
+		from, err := strconv.ParseUint(cCtx.Args().Get(0), 10, 64)
+		if err != nil || from == 0 {
+			fmt.Println("Error! from must be a momentum height greater than 0")
+			return err
+		}
+		count, err := strconv.ParseUint(cCtx.Args().Get(1), 10, 64)
+		if err != nil || count == 0 || count > rpcMaxPageSize {
+			fmt.Println("Error! count must be between 1 and", rpcMaxPageSize)
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+
+		list, err := z.Ledger.GetMomentumsByHeight(from, count)
+		if err != nil {
+			fmt.Println("Error getting momentums:", err)
+			return err
+		}
+		if len(list.List) == 0 {
+			fmt.Println("No momentums found from height", from)
+		}
+		for _, m := range list.List {
+			printMomentum(m)
+		}
+
+		if cCtx.Bool(momentumWatchFlag.Name) {
+			return watchMomentums(z, from+uint64(len(list.List)), false)
+		}
+		return nil
+	},
+}
+
+var znnCliMomentumDetailed = &cli.Command{
+	Name:  "momentum.detailed",
+	Usage: "height",
+	Flags: []cli.Flag{
+		momentumWatchFlag,
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("momentum.detailed height")
+			return nil
+		}
+		height, err := strconv.ParseUint(cCtx.Args().Get(0), 10, 64)
+		if err != nil || height == 0 {
+			fmt.Println("Error! height must be a momentum height greater than 0")
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+
+		list, err := z.Ledger.GetDetailedMomentumsByHeight(height, 1)
+		if err != nil {
+			fmt.Println("Error getting detailed momentum:", err)
+			return err
+		}
+		if len(list.List) == 0 {
+			fmt.Println("No momentum found at height", height)
+		} else {
+			printDetailedMomentum(list.List[0])
+		}
+
+		if cCtx.Bool(momentumWatchFlag.Name) {
+			return watchMomentums(z, height+1, true)
+		}
+		return nil
+	},
+}
+
+var blockTypeNames = map[uint64]string{
+	nom.BlockTypeGenesisReceive:  "genesis receive",
+	nom.BlockTypeUserSend:        "user send",
+	nom.BlockTypeUserReceive:     "user receive",
+	nom.BlockTypeContractSend:    "contract send",
+	nom.BlockTypeContractReceive: "contract receive",
+}
+
+func formatBlockType(blockType uint64) string {
+	if name, ok := blockTypeNames[blockType]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", blockType)
+}
+
+// embeddedContractABIs are the ABIs of the embedded contracts. Methods
+// not found in them are looked up in the ABI shared by all contracts.
+var embeddedContractABIs = map[types.Address]abi.ABIContract{
+	types.PlasmaContract:      definition.ABIPlasma,
+	types.PillarContract:      definition.ABIPillars,
+	types.TokenContract:       definition.ABIToken,
+	types.SentinelContract:    definition.ABISentinel,
+	types.SwapContract:        definition.ABISwap,
+	types.StakeContract:       definition.ABIStake,
+	types.SporkContract:       definition.ABISpork,
+	types.AcceleratorContract: definition.ABIAccelerator,
+	types.LiquidityContract:   definition.ABILiquidity,
+	types.BridgeContract:      definition.ABIBridge,
+}
+
+// decodeEmbeddedCall returns the method and arguments of a call to an
+// embedded contract.
+func decodeEmbeddedCall(contract types.Address, data []byte) (*abi.Method, []interface{}, error) {
+	if len(data) < 4 {
+		return nil, nil, errors.New("call data is shorter than a method selector")
+	}
+	method, err := definition.ABICommon.MethodById(data[:4])
+	if contractABI, ok := embeddedContractABIs[contract]; ok {
+		if m, cerr := contractABI.MethodById(data[:4]); cerr == nil {
+			method, err = m, nil
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	args, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil {
+		return method, nil, err
+	}
+	return method, args, nil
+}
+
+func formatCallArgument(arg interface{}) string {
+	if b, ok := arg.([]byte); ok {
+		return hex.EncodeToString(b)
+	}
+	return fmt.Sprint(arg)
+}
+
+// printBlockData prints the data field of a block. Calls to embedded
+// contracts are decoded with the contract ABIs, anything else is shown as
+// text when printable.
+func printBlockData(block *api.AccountBlock) {
+	if len(block.Data) == 0 {
+		return
+	}
+	fmt.Println("  Data:", hex.EncodeToString(block.Data))
+	if name, ok := embeddedContractName(block.ToAddress); ok {
+		method, args, err := decodeEmbeddedCall(block.ToAddress, block.Data)
+		if method == nil {
+			fmt.Println("  Embedded call:", name, "with undecodable data:", err)
+			return
+		}
+		fmt.Println("  Embedded call:", name+"."+method.Name)
+		if err != nil {
+			fmt.Println("    Undecodable arguments:", err)
+			return
+		}
+		for i, input := range method.Inputs {
+			if i >= len(args) {
+				break
+			}
+			fmt.Printf("    %s (%s): %s\n", input.Name, input.Type.String(), formatCallArgument(args[i]))
+		}
+	} else if utf8.Valid(block.Data) && strings.IndexFunc(string(block.Data), func(r rune) bool { return !unicode.IsPrint(r) && !unicode.IsSpace(r) }) == -1 {
+		fmt.Println("  Data (text):", string(block.Data))
+	}
+}
+
+func printAccountBlock(block *api.AccountBlock) {
+	fmt.Println("Account block", block.Hash.String())
+	fmt.Println("  Type:", formatBlockType(block.BlockType))
+	fmt.Println("  Height:", block.Height)
+	fmt.Println("  From:", block.Address.String())
+	if block.BlockType == nom.BlockTypeUserSend || block.BlockType == nom.BlockTypeContractSend {
+		fmt.Println("  To:", block.ToAddress.String())
+	} else {
+		fmt.Println("  FromBlockHash:", block.FromBlockHash.String())
+	}
+	if block.TokenInfo != nil && block.Amount != nil {
+		fmt.Println("  Amount:", formatAmount(block.Amount, block.TokenInfo.Decimals), block.TokenInfo.TokenSymbol, block.TokenStandard.String())
+	}
+	if block.ConfirmationDetail != nil {
+		fmt.Println("  Confirmations:", block.ConfirmationDetail.NumConfirmations)
+		fmt.Println("  Momentum height:", block.ConfirmationDetail.MomentumHeight)
+		fmt.Println("  Momentum hash:", block.ConfirmationDetail.MomentumHash.String())
+	} else {
+		fmt.Println("  Unconfirmed")
+	}
+	if block.PairedAccountBlock != nil {
+		fmt.Println("  Paired block:", block.PairedAccountBlock.Hash.String(), "("+formatBlockType(block.PairedAccountBlock.BlockType)+")")
+	}
+	for _, descendant := range block.DescendantBlocks {
+		fmt.Println("  Descendant block:", descendant.Hash.String(), "("+formatBlockType(descendant.BlockType)+")")
+	}
+	printBlockData(block)
+}
+
+var znnCliBlockGet = &cli.Command{
+	Name:  "block.get",
+	Usage: "hash",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("block.get hash")
+			return nil
+		}
+		hash, err := types.HexToHash(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error! Invalid block hash:", err)
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+
+		block, err := z.Ledger.GetAccountBlockByHash(hash)
+		if err != nil {
+			fmt.Println("Error getting account block:", err)
+			return err
+		}
+		if block == nil {
+			fmt.Println("No account block found with hash", hash)
+			return nil
+		}
+		printAccountBlock(block)
+		return nil
+	},
+}
+
+var znnCliAccountBlocks = &cli.Command{
+	Name:    "account.blocks",
+	Aliases: []string{"account.chain"},
+	Usage:   "address|@contact fromHeight count",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 3 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("account.blocks address|@contact fromHeight count")
+			return nil
+		}
+		address, err := resolveAddress(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error! Invalid address:", err)
+			return err
+		}
+		from, err := strconv.ParseUint(cCtx.Args().Get(1), 10, 64)
+		if err != nil || from == 0 {
+			fmt.Println("Error! fromHeight must be an account height greater than 0")
+			return err
+		}
+		count, err := strconv.ParseUint(cCtx.Args().Get(2), 10, 64)
+		if err != nil || count == 0 || count > rpcMaxPageSize {
+			fmt.Println("Error! count must be between 1 and", rpcMaxPageSize)
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+
+		list, err := z.Ledger.GetAccountBlocksByHeight(address, from, count)
+		if err != nil {
+			fmt.Println("Error getting account blocks:", err)
+			return err
+		}
+		if len(list.List) == 0 {
+			fmt.Println("No account blocks found for", address, "from height", from)
+			return nil
+		}
+		fmt.Println("Showing", len(list.List), "of", list.Count, "account block(s) for", address)
+		for _, block := range list.List {
+			printAccountBlock(block)
+		}
+		return nil
+	},
+}
+
 var znnCliWalletCreateNew = &cli.Command{
 	Name:  "wallet.createNew",
 	Usage: "passphrase [keyStoreName]",
@@ -445,7 +1061,7 @@ var znnCliPillarCollect = &cli.Command{
 			fmt.Println("Error templating pillar collect tx:", err)
 			return err
 		}
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(cCtx, z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending pillar collect tx:", err)
 			return err
@@ -486,7 +1102,7 @@ var znnCliPillarDelegate = &cli.Command{
 			return err
 		}
 		fmt.Println("Delegating to Pillar", pillar)
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(cCtx, z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending pillar delegate tx:", err)
 			return err
@@ -523,7 +1139,7 @@ var znnCliPillarUndelegate = &cli.Command{
 			return err
 		}
 		fmt.Println("Undelegating ...")
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(cCtx, z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending pillar undelegate tx:", err)
 			return err
@@ -568,59 +1184,349 @@ var znnCliPlasmaGet = &cli.Command{
 	},
 }
 
+var znnCliPlasmaFuse = &cli.Command{
+	Name:  "plasma.fuse",
+	Usage: "toAddress|@contact amount",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 2 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("plasma.fuse toAddress|@contact amount")
+			return nil
+		}
+		beneficiary, err := resolveAddress(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error! Invalid address:", err)
+			return err
+		}
+		amount, err := parseAmount(cCtx.Args().Get(1), QsrDecimals)
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
+		if amount.Cmp(fuseMinAmount) < 0 {
+			fmt.Println("Error! Minimum fuse amount is", formatAmount(fuseMinAmount, QsrDecimals), "QSR")
+			return nil
+		}
+
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+		template, err := z.Embedded.Plasma.Fuse(beneficiary, amount)
+		if err != nil {
+			fmt.Println("Error templating plasma fuse tx:", err)
+			return err
+		}
+		fmt.Println("Fusing", formatAmount(amount, QsrDecimals), "QSR to", beneficiary)
+		_, err = sendBlock(cCtx, z, template, kp)
+		if err != nil {
+			fmt.Println("Error sending plasma fuse tx:", err)
+			return err
+		}
+
+		fmt.Println("Done")
+		return nil
+	},
+}
+
+// plasmaEstimateTemplates builds a representative block for each
+// transaction type supported by plasma.estimate. Receives are not included
+// since their required PoW depends on the send block being received.
+var plasmaEstimateTemplates = map[string]func(z *zdk.Zdk, address types.Address) (*nom.AccountBlock, error){
+	"send": func(z *zdk.Zdk, address types.Address) (*nom.AccountBlock, error) {
+		return &nom.AccountBlock{BlockType: nom.BlockTypeUserSend, ToAddress: address, Data: []byte{}}, nil
+	},
+	"pillar.collect": func(z *zdk.Zdk, address types.Address) (*nom.AccountBlock, error) {
+		return z.Embedded.Pillar.CollectReward()
+	},
+	"pillar.delegate": func(z *zdk.Zdk, address types.Address) (*nom.AccountBlock, error) {
+		return z.Embedded.Pillar.Delegate("pillar")
+	},
+	"pillar.undelegate": func(z *zdk.Zdk, address types.Address) (*nom.AccountBlock, error) {
+		return z.Embedded.Pillar.Undelegate()
+	},
+	"sentinel.collect": func(z *zdk.Zdk, address types.Address) (*nom.AccountBlock, error) {
+		return z.Embedded.Sentinel.CollectReward()
+	},
+	"stake.collect": func(z *zdk.Zdk, address types.Address) (*nom.AccountBlock, error) {
+		return z.Embedded.Stake.CollectReward()
+	},
+}
+
+var znnCliPlasmaEstimate = &cli.Command{
+	Name:  "plasma.estimate",
+	Usage: "send|pillar.collect|pillar.delegate|pillar.undelegate|sentinel.collect|stake.collect",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("plasma.estimate type")
+			return nil
+		}
+		txType := cCtx.Args().Get(0)
+		build, ok := plasmaEstimateTemplates[txType]
+		if !ok {
+			fmt.Println("Error! Unknown transaction type", txType)
+			fmt.Println("Supported types:", cCtx.Command.Usage)
+			return nil
+		}
+
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
+		}
+		template, err := build(z, kp.Address())
+		if err != nil {
+			fmt.Println("Error templating", txType, "tx:", err)
+			return err
+		}
+		required, err := getRequiredPoW(z, template, kp.Address())
+		if err != nil {
+			fmt.Println("Error estimating required plasma:", err)
+			return err
+		}
+		plasmaInfo, err := z.Embedded.Plasma.Get(kp.Address())
+		if err != nil {
+			fmt.Println("Error getting plasma info:", err)
+			return err
+		}
+
+		fmt.Printf("A %s tx requires %v plasma\n", txType, required.BasePlasma)
+		fmt.Printf("%s has %v/%v plasma with %v QSR fused.\n", kp.Address(), plasmaInfo.CurrentPlasma, plasmaInfo.MaxPlasma, formatAmount(plasmaInfo.QsrAmount, QsrDecimals))
+		if required.RequiredDifficulty == 0 {
+			fmt.Println("Plasma is sufficient, no PoW required")
+		} else {
+			fmt.Println("Plasma is insufficient, PoW with difficulty", required.RequiredDifficulty, "required")
+		}
+		return nil
+	},
+}
+
+// sporkCachePath is where the last spork list fetched for the current
+// chain identifier is kept.
+func sporkCachePath() string {
+	return filepath.Join(nomctlDir, fmt.Sprintf("sporks-%d.json", chainId))
+}
+
+type sporkCache struct {
+	Url       string              `json:"url"`
+	ChangedAt int64               `json:"changedAt"`
+	Sporks    []*definition.Spork `json:"sporks"`
+}
+
+func parseSporkId(s string) (types.Hash, error) {
+	id, err := types.HexToHash(s)
+	if err != nil {
+		return types.Hash{}, fmt.Errorf("invalid spork id %q: %w", s, err)
+	}
+	return id, nil
+}
+
+// getSporks fetches every spork and refreshes the local spork cache.
+func getSporks(z *zdk.Zdk) ([]*definition.Spork, error) {
+	sporkList, err := z.Embedded.Spork.GetAll(0, rpcMaxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	_ = updateSporkCache(url, sporkList.List)
+	return sporkList.List, nil
+}
+
+// updateSporkCache writes the spork cache unless it already holds the same
+// sporks from the same node, so polling commands don't rewrite it on every
+// request. ChangedAt is therefore when the cached sporks last changed.
+func updateSporkCache(url string, sporks []*definition.Spork) error {
+	if cache, err := readSporkCache(); err == nil && cache.Url == url && cache.ChangedAt != 0 {
+		cached, err1 := json.Marshal(cache.Sporks)
+		fetched, err2 := json.Marshal(sporks)
+		if err1 == nil && err2 == nil && bytes.Equal(cached, fetched) {
+			return nil
+		}
+	}
+	data, err := json.MarshalIndent(sporkCache{Url: url, ChangedAt: time.Now().Unix(), Sporks: sporks}, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sporkCachePath(), data, 0600)
+}
+
+func readSporkCache() (*sporkCache, error) {
+	data, err := os.ReadFile(sporkCachePath())
+	if err != nil {
+		return nil, err
+	}
+	cache := &sporkCache{}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func findSpork(sporks []*definition.Spork, id types.Hash) *definition.Spork {
+	for _, s := range sporks {
+		if s.Id == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func printSpork(s *definition.Spork) {
+	fmt.Printf("Name: %v\n", s.Name)
+	fmt.Printf("  Description: %v\n", s.Description)
+	fmt.Printf("  Activated: %v\n", s.Activated)
+	if s.Activated {
+		fmt.Printf("  EnforcementHeight: %v\n", s.EnforcementHeight)
+	}
+	fmt.Printf("  Hash: %v\n", s.Id)
+	if _, ok := types.ImplementedSporksMap[s.Id]; ok {
+		fmt.Printf("  Implemented by this build of go-zenon\n")
+	}
+}
+
 var znnCliSporkList = &cli.Command{
 	Name:  "spork.list",
 	Usage: "",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "activated",
+			Usage: "Only list activated sporks",
+		},
+		&cli.BoolFlag{
+			Name:  "pending",
+			Usage: "Only list sporks that are not activated",
+		},
+		&cli.BoolFlag{
+			Name:  "cached",
+			Usage: "List the sporks from the last fetch without connecting",
+		},
+	},
 	Action: func(cCtx *cli.Context) error {
 		if cCtx.NArg() != 0 {
 			fmt.Println("Incorrect number of arguments. Expected:")
 			fmt.Println("spork.list")
 			return nil
 		}
+		if cCtx.Bool("activated") && cCtx.Bool("pending") {
+			fmt.Println("Error! Use either --activated or --pending")
+			return nil
+		}
+
+		var sporks []*definition.Spork
+		if cCtx.Bool("cached") {
+			cache, err := readSporkCache()
+			if err != nil {
+				fmt.Println("Error reading spork cache:", err)
+				return err
+			}
+			fmt.Println("Sporks cached from", cache.Url, "last changed at", time.Unix(cache.ChangedAt, 0).UTC().Format(time.RFC3339))
+			sporks = cache.Sporks
+		} else {
+			z, err := connect(url, chainId)
+			if err != nil {
+				fmt.Println("Error connecting to Zenon Network:", err)
+				return err
+			}
+			sporks, err = getSporks(z)
+			if err != nil {
+				fmt.Println("Error getting spork list:", err)
+				return err
+			}
+		}
+
+		listed := 0
+		for _, s := range sporks {
+			if (cCtx.Bool("activated") && !s.Activated) || (cCtx.Bool("pending") && s.Activated) {
+				continue
+			}
+			if listed == 0 {
+				fmt.Println("Sporks:")
+			}
+			printSpork(s)
+			listed++
+		}
+		if listed == 0 {
+			fmt.Println("No sporks found")
+		}
+
+		missing := make([]types.Hash, 0)
+		for id := range types.ImplementedSporksMap {
+			if findSpork(sporks, id) == nil {
+				missing = append(missing, id)
+			}
+		}
+		sort.Slice(missing, func(i, j int) bool {
+			return bytes.Compare(missing[i].Bytes(), missing[j].Bytes()) < 0
+		})
+		if len(missing) > 0 {
+			fmt.Println("Sporks implemented by this build of go-zenon but not created on this network:")
+			for _, id := range missing {
+				fmt.Printf("  %v\n", id)
+			}
+		}
+
+		return nil
+	},
+}
+
+var znnCliSporkGet = &cli.Command{
+	Name:  "spork.get",
+	Usage: "id",
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 1 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("spork.get id")
+			return nil
+		}
+		id, err := parseSporkId(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
 
 		z, err := connect(url, chainId)
 		if err != nil {
 			fmt.Println("Error connecting to Zenon Network:", err)
 			return err
 		}
-		sporkList, err := z.Embedded.Spork.GetAll(0, rpcMaxPageSize)
+		sporks, err := getSporks(z)
 		if err != nil {
 			fmt.Println("Error getting spork list:", err)
 			return err
 		}
-
-		if len(sporkList.List) == 0 {
-			fmt.Println("No sporks found")
-		} else {
-			fmt.Println("Sporks:")
-			for _, s := range sporkList.List {
-				fmt.Printf("Name: %v\n", s.Name)
-				fmt.Printf("  Description: %v\n", s.Description)
-				fmt.Printf("  Activated: %v\n", s.Activated)
-				if s.Activated {
-					fmt.Printf("  EnforcementHeight: %v\n", s.EnforcementHeight)
-				}
-				fmt.Printf("  Hash: %v\n", s.Id)
-			}
+		s := findSpork(sporks, id)
+		if s == nil {
+			fmt.Println("No spork found with id", id)
+			return nil
 		}
-
+		printSpork(s)
 		return nil
 	},
 }
 
-var znnCliSporkCreate = &cli.Command{
-	Name:  "spork.create",
-	Usage: "name description",
+var znnCliSporkWait = &cli.Command{
+	Name:  "spork.wait",
+	Usage: "id",
 	Action: func(cCtx *cli.Context) error {
-		if cCtx.NArg() != 2 {
+		if cCtx.NArg() != 1 {
 			fmt.Println("Incorrect number of arguments. Expected:")
-			fmt.Println("spork.list name description")
+			fmt.Println("spork.wait id")
 			return nil
 		}
-
-		kp, err := getZnnCliSigner(walletDir, cCtx)
+		id, err := parseSporkId(cCtx.Args().Get(0))
 		if err != nil {
-			fmt.Println("Error getting signer:", err)
+			fmt.Println("Error!", err)
 			return err
 		}
 
@@ -630,6 +1536,83 @@ var znnCliSporkCreate = &cli.Command{
 			return err
 		}
 
+		timeout := cCtx.Duration("timeout")
+		deadline := time.Now().Add(timeout)
+		var lastStatus string
+		for {
+			sporks, err := getSporks(z)
+			if err != nil {
+				fmt.Println("Error getting spork list:", err)
+				return err
+			}
+			frontier, err := z.Ledger.GetFrontierMomentum()
+			if err != nil {
+				fmt.Println("Error getting frontier momentum:", err)
+				return err
+			}
+
+			var status string
+			s := findSpork(sporks, id)
+			switch {
+			case s == nil:
+				status = "Waiting for spork " + id.String() + " to be created"
+			case !s.Activated:
+				status = "Waiting for spork " + s.Name + " to be activated"
+			case frontier.Height >= s.EnforcementHeight:
+				fmt.Println("Spork", s.Name, "is enforced since momentum", s.EnforcementHeight)
+				return nil
+			default:
+				status = fmt.Sprintf("Waiting for spork %s to be enforced at momentum %d", s.Name, s.EnforcementHeight)
+			}
+			if status != lastStatus {
+				fmt.Println(status)
+				lastStatus = status
+			}
+			if time.Now().After(deadline) {
+				err := fmt.Errorf("timed out after %v waiting for spork %s", timeout, id)
+				fmt.Println("Error!", err)
+				return err
+			}
+			time.Sleep(momentumPollInterval)
+		}
+	},
+}
+
+// waitForSpork polls the spork contract until the spork created by the
+// send block with the given hash shows up or timeout elapses.
+func waitForSpork(z *zdk.Zdk, createHash types.Hash, timeout time.Duration) (*definition.Spork, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		sporks, err := getSporks(z)
+		if err != nil {
+			return nil, err
+		}
+		if s := findSpork(sporks, createHash); s != nil {
+			return s, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %v waiting for the spork created by %s", timeout, createHash)
+		}
+		time.Sleep(momentumPollInterval)
+	}
+}
+
+var znnCliSporkCreate = &cli.Command{
+	Name:  "spork.create",
+	Usage: "name description",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "activate",
+			Usage: "Wait for the spork to be created and activate it",
+		},
+	},
+	Action: func(cCtx *cli.Context) error {
+		if cCtx.NArg() != 2 {
+			fmt.Println("Incorrect number of arguments. Expected:")
+			fmt.Println("spork.create name description")
+			return nil
+		}
+
 		name := cCtx.Args().Get(0)
 		if len(name) < constants.SporkNameMinLength || len(name) > constants.SporkNameMaxLength {
 			fmt.Println("Spork name must be", constants.SporkNameMinLength, "to", constants.SporkNameMaxLength, "characters in length")
@@ -638,6 +1621,19 @@ var znnCliSporkCreate = &cli.Command{
 		description := cCtx.Args().Get(1)
 		if len(description) > constants.SporkDescriptionMaxLength {
 			fmt.Println("Spork description cannot exceed", constants.SporkDescriptionMaxLength, "characters in length")
+			return nil
+		}
+
+		kp, err := getZnnCliSigner(walletDir, cCtx)
+		if err != nil {
+			fmt.Println("Error getting signer:", err)
+			return err
+		}
+
+		z, err := connect(url, chainId)
+		if err != nil {
+			fmt.Println("Error connecting to Zenon Network:", err)
+			return err
 		}
 
 		template, err := z.Embedded.Spork.Create(name, description)
@@ -646,12 +1642,37 @@ var znnCliSporkCreate = &cli.Command{
 			return err
 		}
 		fmt.Println("Creating spork...")
-		_, err = utils.Send(z, template, kp, false)
+		block, err := sendBlock(cCtx, z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending spork create tx:", err)
 			return err
 		}
 
+		if cCtx.Bool("activate") {
+			// the spork id is the hash of the create block, the spork exists
+			// once the contract has received it
+			fmt.Println("Waiting for spork", name, "to be created ...")
+			s, err := waitForSpork(z, block.Hash, cCtx.Duration("timeout"))
+			if err != nil {
+				fmt.Println("Error waiting for spork:", err)
+				return err
+			}
+			fmt.Println("Created spork", s.Name, "with id", s.Id)
+
+			template, err := z.Embedded.Spork.Activate(s.Id)
+			if err != nil {
+				fmt.Println("Error templating spork activate tx:", err)
+				return err
+			}
+			fmt.Println("Activating spork...")
+			_, err = sendBlock(cCtx, z, template, kp)
+			if err != nil {
+				fmt.Println("Error sending spork activate tx:", err)
+				fmt.Println("Activate it later with: spork.activate", s.Id)
+				return err
+			}
+		}
+
 		fmt.Println("Done")
 		return nil
 	},
@@ -666,6 +1687,11 @@ var znnCliSporkActivate = &cli.Command{
 			fmt.Println("spork.activate id")
 			return nil
 		}
+		id, err := parseSporkId(cCtx.Args().Get(0))
+		if err != nil {
+			fmt.Println("Error!", err)
+			return err
+		}
 
 		kp, err := getZnnCliSigner(walletDir, cCtx)
 		if err != nil {
@@ -679,15 +1705,13 @@ var znnCliSporkActivate = &cli.Command{
 			return err
 		}
 
-		id := types.HexToHashPanic(cCtx.Args().Get(0))
-
 		template, err := z.Embedded.Spork.Activate(id)
 		if err != nil {
 			fmt.Println("Error templating spork activate tx:", err)
 			return err
 		}
 		fmt.Println("Activating spork...")
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(cCtx, z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending spork activate tx:", err)
 			return err
@@ -761,7 +1785,7 @@ var znnCliSentinelCollect = &cli.Command{
 			fmt.Println("Error templating sentinel collect tx:", err)
 			return err
 		}
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(cCtx, z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending sentinel collect tx:", err)
 			return err
@@ -837,7 +1861,7 @@ var znnCliStakeCollect = &cli.Command{
 			fmt.Println("Error templating stake collect tx:", err)
 			return err
 		}
-		_, err = utils.Send(z, template, kp, false)
+		_, err = sendBlock(cCtx, z, template, kp)
 		if err != nil {
 			fmt.Println("Error sending stake collect tx:", err)
 			return err
@@ -850,25 +1874,45 @@ var znnCliStakeCollect = &cli.Command{
 }
 
 var znnCliSubcommands = []*cli.Command{
+	znnCliSend,
+	znnCliAddressBookAdd,
+	znnCliAddressBookRemove,
+	znnCliAddressBookList,
+	znnCliSendBatch,
+	znnCliProposalCreate,
+	znnCliProposalShow,
+	znnCliProposalApprove,
+	znnCliProposalExecute,
 	znnCliBalance,
 	znnCliFrontierMomentum,
+	znnCliMomentumGet,
+	znnCliMomentumRange,
+	znnCliMomentumDetailed,
+	znnCliBlockGet,
+	znnCliAccountBlocks,
 	znnCliWalletCreateNew,
 	znnCliWalletCreateFromMnemonic,
 	znnCliWalletList,
 	//		znnCliWalletDeriveAddresses,
 	znnCliPlasmaGet,
+	znnCliPlasmaEstimate,
+	znnCliPlasmaFuse,
 	znnCliPillarList,
 	znnCliPillarUncollected,
 	znnCliPillarCollect,
 	znnCliPillarDelegate,
 	znnCliPillarUndelegate,
 	znnCliSporkList,
+	znnCliSporkGet,
+	znnCliSporkWait,
 	znnCliSporkCreate,
 	znnCliSporkActivate,
 	znnCliSentinelUncollected,
 	znnCliSentinelCollect,
 	znnCliStakeUncollected,
 	znnCliStakeCollect,
+	znnCliSwapRetrieve,
+	znnCliDevnetBootstrap,
 	znnCliReceiveAll,
 	znnCliUnreceived,
 }
@@ -902,12 +1946,40 @@ var znnCliCommand = cli.Command{
 			Aliases: []string{"k"},
 			Usage:   "Select the local keyStore",
 		},
+		&cli.StringFlag{
+			Name:  "signer",
+			Usage: "Signer backend: keyfile, unix:<socket>, http(s)://<url> or exec:<command>",
+			Value: signerKeyFile,
+		},
 		&cli.IntFlag{
 			Name:    "index",
 			Aliases: []string{"i"},
 			Usage:   "Address index",
 			Value:   0,
 		},
+		&cli.StringFlag{
+			Name:  "pow",
+			Usage: "PoW mode: auto generates PoW when plasma is insufficient, always uses PoW instead of plasma, never fails when plasma is insufficient",
+			Value: powAuto,
+		},
+		&cli.IntFlag{
+			Name:  "pow-threads",
+			Usage: "Number of threads used to generate PoW, defaults to all CPU cores",
+		},
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "Wait until sent blocks are confirmed in a momentum",
+		},
+		&cli.Uint64Flag{
+			Name:  "confirmations",
+			Usage: "Number of momentum confirmations to wait for when using --wait",
+			Value: 1,
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "Maximum time to wait for confirmations when using --wait, or for spork.wait",
+			Value: 5 * time.Minute,
+		},
 		&cli.BoolFlag{
 			Name:    "verbose",
 			Aliases: []string{"v"},
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+func TestUpdateSporkCache(t *testing.T) {
+	defer func(dir string) { nomctlDir = dir }(nomctlDir)
+	nomctlDir = t.TempDir()
+
+	sporks := []*definition.Spork{{Id: types.NewHash([]byte("spork")), Name: "spork"}}
+	write := func(url string, sporks []*definition.Spork) *sporkCache {
+		t.Helper()
+		if err := updateSporkCache(url, sporks); err != nil {
+			t.Fatal(err)
+		}
+		cache, err := readSporkCache()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cache
+	}
+	// ChangedAt only changes when the cache is rewritten
+	markWritten := func(cache *sporkCache) {
+		t.Helper()
+		cache.ChangedAt = 1
+		data, err := json.Marshal(cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(sporkCachePath(), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// caches written before changedAt existed are rewritten once
+	old := &sporkCache{Url: "ws://a", Sporks: sporks}
+	data, err := json.Marshal(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sporkCachePath(), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cache := write("ws://a", sporks); cache.ChangedAt == 0 {
+		t.Error("cache without a change time was not rewritten")
+	}
+
+	markWritten(write("ws://a", sporks))
+	if cache := write("ws://a", sporks); cache.ChangedAt != 1 {
+		t.Error("unchanged sporks rewrote the cache")
+	}
+	if cache := write("ws://b", sporks); cache.ChangedAt == 1 || cache.Url != "ws://b" {
+		t.Error("sporks from another node did not rewrite the cache")
+	}
+
+	markWritten(write("ws://b", sporks))
+	activated := []*definition.Spork{{Id: sporks[0].Id, Name: "spork", Activated: true, EnforcementHeight: 10}}
+	cache := write("ws://b", activated)
+	if cache.ChangedAt == 1 || !cache.Sporks[0].Activated {
+		t.Error("changed sporks did not rewrite the cache")
+	}
+}